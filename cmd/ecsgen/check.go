@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/gen0cide/ecsgen/generator"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// check compares the files the generators wrote to memory with the files on disk they would
+// otherwise have been written to, printing a unified diff for each file that differs. It returns
+// the paths of the files that are out of date.
+func check(generated *generator.MemoryOutput, dir *generator.DirOutput) ([]string, error) {
+	files := generated.Files()
+	stale := []string{}
+
+	// the names are sorted so the diffs are printed in a stable order
+	for _, name := range generated.Names() {
+		path, err := dir.Path(name)
+		if err != nil {
+			return nil, err
+		}
+
+		fromFile := path
+
+		existing, err := ioutil.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("error reading %s: %v", path, err)
+			}
+
+			fromFile = os.DevNull
+		}
+
+		// a missing file is out of date, even if the generated file is empty
+		if fromFile != os.DevNull && bytes.Equal(existing, files[name]) {
+			continue
+		}
+
+		stale = append(stale, path)
+
+		// SplitLines returns a single empty line for empty contents
+		before := []string{}
+		if len(existing) > 0 {
+			before = difflib.SplitLines(string(existing))
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        before,
+			B:        difflib.SplitLines(string(files[name])),
+			FromFile: fromFile,
+			ToFile:   path + " (generated)",
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating diff for %s: %v", path, err)
+		}
+
+		fmt.Print(diff)
+	}
+
+	return stale, nil
+}
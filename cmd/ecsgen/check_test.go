@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gen0cide/ecsgen/generator"
+)
+
+// captureStdout returns everything written to os.Stdout while f runs.
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	f()
+
+	w.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  map[string]string
+		wantStale []string
+		wantDiff  []string
+	}{
+		{
+			name:     "up to date",
+			existing: map[string]string{"ecs.go": "package ecs\n", "sub/ecs.go": ""},
+		},
+		{
+			name:      "stale file",
+			existing:  map[string]string{"ecs.go": "package old\n", "sub/ecs.go": ""},
+			wantStale: []string{"ecs.go"},
+			wantDiff:  []string{"--- ecs.go", "+++ ecs.go (generated)", "-package old", "+package ecs"},
+		},
+		{
+			name:      "missing file",
+			existing:  map[string]string{"ecs.go": "package ecs\n"},
+			wantStale: []string{"sub/ecs.go"},
+		},
+		{
+			name:      "missing files are diffed against nothing",
+			existing:  map[string]string{"sub/ecs.go": ""},
+			wantStale: []string{"ecs.go"},
+			wantDiff:  []string{"--- " + os.DevNull, "+++ ecs.go (generated)", "+package ecs"},
+		},
+		{
+			name:     "extra files are not checked",
+			existing: map[string]string{"ecs.go": "package ecs\n", "sub/ecs.go": "", "extra.go": "package extra\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range tt.existing {
+				path := filepath.Join(dir, name)
+
+				err := os.MkdirAll(filepath.Dir(path), 0755)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = ioutil.WriteFile(path, []byte(contents), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			generated := generator.NewMemoryOutput()
+			generated.WriteFile("ecs.go", []byte("package ecs\n"))
+			generated.WriteFile("sub/ecs.go", []byte(""))
+
+			var stale []string
+			var err error
+
+			diff := captureStdout(t, func() {
+				stale, err = check(generated, generator.NewDirOutput(dir))
+			})
+			if err != nil {
+				t.Fatalf("check() error = %v", err)
+			}
+
+			want := []string{}
+			for _, name := range tt.wantStale {
+				want = append(want, filepath.Join(dir, name))
+			}
+
+			if !reflect.DeepEqual(stale, want) {
+				t.Errorf("check() = %v, want %v", stale, want)
+			}
+
+			if len(tt.wantStale) == 0 && diff != "" {
+				t.Errorf("check() printed a diff for up to date files:\n%s", diff)
+			}
+
+			for _, line := range tt.wantDiff {
+				line = strings.Replace(line, "ecs.go", filepath.Join(dir, "ecs.go"), 1)
+				if !strings.Contains(diff, line+"\n") {
+					t.Errorf("diff does not contain %q:\n%s", line, diff)
+				}
+			}
+		})
+	}
+}
+
+// TestCheckExitCode runs ecsgen in check mode as a subprocess. When ECSGEN_TEST_MAIN is set, it
+// runs main with the arguments after "--" instead, so the test binary can be used as ecsgen.
+func TestCheckExitCode(t *testing.T) {
+	if os.Getenv("ECSGEN_TEST_MAIN") != "" {
+		for idx, arg := range os.Args {
+			if arg == "--" {
+				os.Args = append([]string{"ecsgen"}, os.Args[idx+1:]...)
+				break
+			}
+		}
+
+		main()
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+
+	generate := func(extra ...string) error {
+		args := []string{
+			"-test.run=^TestCheckExitCode$", "--", "generate",
+			"--source-file", filepath.Join("testdata", "ecs_flat.yml"),
+			"--output-plugin", "template",
+			"--opt-template-template", filepath.Join("testdata", "fields.txt.tmpl"),
+			"--output", dir,
+		}
+
+		cmd := exec.Command(os.Args[0], append(args, extra...)...)
+		cmd.Env = append(os.Environ(), "ECSGEN_TEST_MAIN=1")
+
+		return cmd.Run()
+	}
+
+	exitCode := func(err error) int {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+
+		if err != nil {
+			t.Fatalf("error running ecsgen: %v", err)
+		}
+
+		return 0
+	}
+
+	path := filepath.Join(dir, "fields.txt")
+
+	// the file does not exist yet
+	if code := exitCode(generate("--check")); code != 1 {
+		t.Errorf("check of a missing file exited with %d, want 1", code)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("check wrote %s", path)
+	}
+
+	if code := exitCode(generate()); code != 0 {
+		t.Fatalf("generate exited with %d, want 0", code)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil || string(contents) != "@timestamp\nclient.ip\nevent.duration\n" {
+		t.Fatalf("generate wrote %q, %v", contents, err)
+	}
+
+	if code := exitCode(generate("--check")); code != 0 {
+		t.Errorf("check of an up to date file exited with %d, want 0", code)
+	}
+
+	err = ioutil.WriteFile(path, []byte("@timestamp\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := exitCode(generate("--check")); code != 1 {
+		t.Errorf("check of a stale file exited with %d, want 1", code)
+	}
+
+	// check leaves the stale file alone
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "@timestamp\n" {
+		t.Errorf("check changed %s to %q", path, contents)
+	}
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/gen0cide/ecsgen"
 	"github.com/gen0cide/ecsgen/config"
+	"github.com/gen0cide/ecsgen/generator"
 	"github.com/gen0cide/ecsgen/loader"
+	"github.com/gen0cide/ecsgen/pipeline"
 	"github.com/urfave/cli"
 )
 
@@ -17,16 +22,32 @@ func init() {
 
 	genConfig = c
 
+	// the flags are created by registerPlugins, once the external plugins are registered
 	generateCommand = &cli.Command{
 		Name:        "generate",
 		Aliases:     []string{"g"},
 		Usage:       "Use to translate ECS YAML definitions into a Go package.",
 		Description: "Takes the input YAML definitions and translates into Idiomatic Go code.",
-		Flags:       genConfig.CLIFlags(),
+		Before:      genConfig.ApplyProjectFile,
 		Action:      generate,
 	}
 }
 
+// registerPlugins registers the external output plugins on the PATH, then creates the flags of
+// the generate command, which include the options of every plugin. It is called by the app's
+// Before hook, so the PATH is not searched for --help or --version, and a plugin that cannot be
+// registered fails the run with an error.
+func registerPlugins() error {
+	err := genConfig.RegisterExternalPlugins()
+	if err != nil {
+		return err
+	}
+
+	generateCommand.Flags = genConfig.CLIFlags()
+
+	return nil
+}
+
 var (
 	genConfig       *config.Config
 	generateCommand *cli.Command
@@ -35,30 +56,124 @@ var (
 func generate(c *cli.Context) error {
 	logger.Info("Running Generator")
 
-	loader, err := loader.NewLoader(genConfig)
+	schemaLoader, err := pipeline.Load(c.Context, genConfig)
 	if err != nil {
+		// collisions are reported the same way as the problems found by validation
+		var diags loader.Diagnostics
+		if errors.As(err, &diags) {
+			logDiagnostics(diags)
+			return fmt.Errorf("schema loading failed with %d problem(s)", len(diags))
+		}
+
 		return err
 	}
 
-	err = loader.Load()
-	if err != nil {
-		return err
+	// warn about filters that did not do anything, as they are most likely typos
+	report := schemaLoader.FilterReport()
+	for _, unused := range report.Unused {
+		logger.Warnf("%s did not match any field", unused)
+	}
+
+	// the report is logged rather than printed, so it does not mix with --output stdout
+	if genConfig.ExplainFilters {
+		for _, d := range report.Decisions {
+			logger.Info(d.String())
+		}
 	}
 
-	root := loader.Root()
+	// report any problems with the schema, failing only on errors
+	diags := schemaLoader.Validate()
+	logDiagnostics(diags)
+
+	if diags.HasErrors() {
+		return fmt.Errorf("schema validation failed with %d error(s)", diags.Errors())
+	}
 
 	generators, err := genConfig.Generators()
 	if err != nil {
 		return err
 	}
 
-	for _, g := range generators {
-		err := g.Execute(root)
+	target, err := genConfig.OutputTarget()
+	if err != nil {
+		return err
+	}
+
+	// check and dry-run modes collect the generated files in memory instead of writing them
+	var out generator.Output
+	var generated *generator.MemoryOutput
+	closeOutput := func() error { return nil }
+
+	if genConfig.Check || genConfig.DryRun {
+		generated = generator.NewMemoryOutput()
+		out = generated
+	} else {
+		out, closeOutput, err = target.Open()
 		if err != nil {
-			return fmt.Errorf("error running %s generator: %v", g.ID(), err)
+			return err
 		}
+	}
+
+	err = pipeline.Execute(c.Context, genConfig, schemaLoader.Root(), out)
+	if err != nil {
+		closeOutput()
+
+		var failed generator.Errors
+		if !errors.As(err, &failed) {
+			return err
+		}
+
+		// point at the exact location within the schema if we can
+		for _, e := range failed {
+			var schemaErr *ecsgen.SchemaError
+			if errors.As(e, &schemaErr) {
+				logger.Errorw("generator failed with schema error", "generator", e.ID, "path", schemaErr.Path, "field", schemaErr.Field, "error", schemaErr.Cause)
+				continue
+			}
+
+			logger.Error(e.Error())
+		}
+
+		return fmt.Errorf("%d of %d generator(s) failed", len(failed), len(generators))
+	}
+
+	for _, g := range generators {
 		logger.Infof("Successfully executed %s generator", g.ID())
 	}
 
+	err = closeOutput()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case genConfig.DryRun:
+		files := generated.Files()
+		for _, name := range generated.Names() {
+			logger.Infof("Would write %s (%d bytes) to %s", name, len(files[name]), target)
+		}
+	case genConfig.Check:
+		stale, err := check(generated, generator.NewDirOutput(target.Path))
+		if err != nil {
+			return err
+		}
+
+		if len(stale) > 0 {
+			return fmt.Errorf("%d generated file(s) are out of date: %s", len(stale), strings.Join(stale, ", "))
+		}
+	}
+
 	return nil
 }
+
+// logDiagnostics logs each Diagnostic at the level of its severity.
+func logDiagnostics(diags loader.Diagnostics) {
+	for _, d := range diags {
+		if d.Severity == loader.SeverityWarning {
+			logger.Warn(d.String())
+			continue
+		}
+
+		logger.Error(d.String())
+	}
+}
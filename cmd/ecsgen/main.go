@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/gen0cide/ecsgen"
@@ -46,7 +47,7 @@ func main() {
 			logger.LogrusLogger().SetLevel(logrus.DebugLevel)
 		}
 
-		return nil
+		return registerPlugins()
 	}
 	app.Commands = []*cli.Command{
 		generateCommand,
@@ -54,6 +55,12 @@ func main() {
 
 	err := app.Run(os.Args)
 	if err != nil {
+		// point at the exact location within the schema if we can
+		var schemaErr *ecsgen.SchemaError
+		if errors.As(err, &schemaErr) {
+			logger.Fatalw("exiting with schema error", "path", schemaErr.Path, "field", schemaErr.Field, "error", schemaErr.Cause)
+		}
+
 		logger.Fatalw("exiting with error", "error", err)
 	}
 }
@@ -3,12 +3,17 @@ package config
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"io/ioutil"
 	"os"
 	"strings"
 
+	"github.com/gen0cide/ecsgen"
 	"github.com/gen0cide/ecsgen/generator"
 	"github.com/gen0cide/ecsgen/generator/debug"
+	"github.com/gen0cide/ecsgen/generator/external"
 	"github.com/gen0cide/ecsgen/generator/gostruct"
+	"github.com/gen0cide/ecsgen/generator/template"
 	"github.com/urfave/cli"
 )
 
@@ -21,22 +26,145 @@ var (
 	ErrNoDefinitionsInSourceFile = errors.New("source directory does not contain any valid ecs definitions")
 )
 
+const (
+	// SourceFormatFlat denotes the ECS generated "ecs_flat.yml" schema format.
+	SourceFormatFlat = "flat"
+
+	// SourceFormatNested denotes the ECS generated "ecs_nested.yml" schema format.
+	SourceFormatNested = "nested"
+
+	// SourceFormatSchemas denotes a directory of upstream ECS "schemas/*.yml" fieldset files.
+	SourceFormatSchemas = "schemas"
+
+	// SourceFormatFields denotes a Beats "fields.yml" file, or a directory containing them.
+	SourceFormatFields = "fields"
+)
+
+const (
+	// CollisionStrategyError fails loading when a scalar field is also used as an object.
+	CollisionStrategyError = "error"
+
+	// CollisionStrategySuffix keeps the object, and moves the scalar field to a sibling
+	// with the collision suffix appended to its name.
+	CollisionStrategySuffix = "suffix"
+
+	// DefaultCollisionSuffix is the default suffix used by CollisionStrategySuffix.
+	DefaultCollisionSuffix = "_value"
+)
+
+var (
+	// CollisionStrategies is the list of supported ways to resolve field and object collisions.
+	CollisionStrategies = []string{
+		CollisionStrategyError,
+		CollisionStrategySuffix,
+	}
+
+	// SourceFormats is the list of schema formats the loader understands.
+	SourceFormats = []string{
+		SourceFormatFlat,
+		SourceFormatNested,
+		SourceFormatSchemas,
+		SourceFormatFields,
+	}
+)
+
 var (
 	// list of the builtin generators
-	builtinGenerators = []generator.Generator{
-		debug.New(),
-		gostruct.New(),
+	builtinGenerators = []generator.Factory{
+		debug.New,
+		gostruct.New,
+		template.New,
 	}
 )
 
 // Config holds the parameters needed for proper generation of Go code.
 type Config struct {
-	SourceFile string
+	ProjectFile    string
+	SourceFile     string
+	SourceFormat   string
+	AllowOverrides bool
+	ExplainFilters bool
+	Check          bool
+	DryRun         bool
+	FailFast       bool
+	Output         string
+
+	CollisionStrategy string
+	CollisionSuffix   string
+
+	customSources *cli.StringSlice
+	whitelist     *cli.StringSlice
+	blacklist     *cli.StringSlice
+	levels        *cli.StringSlice
+	includes      *cli.StringSlice
+	excludes      *cli.StringSlice
+	initialisms   *cli.StringSlice
+	typeOverrides *cli.StringSlice
+	generators    *cli.StringSlice
+	pluginOptions *cli.StringSlice
+	registry      generator.Registry
+
+	// projectPluginOptions holds the plugin options of named instances read from the
+	// project file. They are kept apart from pluginOptions so that options given on
+	// the command line only replace the options they name.
+	projectPluginOptions []string
+
+	// instances holds the enabled generators once they have been created.
+	instances []generator.Generator
+
+	// sources replaces the source file flags for a Config created with New.
+	sources []Source
+
+	// isSet reports whether a flag was given on the command line, in the environment or
+	// in the project file. It is nil unless the Config was created from CLI flags.
+	isSet func(name string) bool
+}
+
+// Source describes a single schema file that should be loaded, along with the format it is in.
+type Source struct {
+	Path   string
+	Format string
+
+	// FS is the file system Path is read from. If it is nil, Path is read from disk.
+	FS fs.FS
+}
+
+// FileSystem returns the file system the Source's Path is read from.
+func (s Source) FileSystem() fs.FS {
+	if s.FS == nil {
+		return osFS{}
+	}
+
+	return s.FS
+}
+
+// osFS is the file system of sources that are read from disk. Unlike os.DirFS, it
+// accepts the absolute and relative paths that are given on the command line.
+type osFS struct{}
+
+// Open implements the fs.FS interface.
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// Stat implements the fs.StatFS interface.
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// ReadFile implements the fs.ReadFileFS interface.
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+// ReadDir implements the fs.ReadDirFS interface.
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
 
-	whitelist  *cli.StringSlice
-	blacklist  *cli.StringSlice
-	generators *cli.StringSlice
-	registry   generator.Registry
+// String implements the fmt.Stringer interface.
+func (s Source) String() string {
+	return s.Path
 }
 
 // NewEmptyConfig is a constructor for an empty Config object.
@@ -51,13 +179,42 @@ func NewEmptyConfig() (*Config, error) {
 	}
 
 	return &Config{
-		whitelist:  cli.NewStringSlice(),
-		blacklist:  cli.NewStringSlice(),
-		generators: cli.NewStringSlice(),
-		registry:   registry,
+		SourceFormat:      SourceFormatFlat,
+		Output:            DefaultOutput,
+		CollisionStrategy: CollisionStrategyError,
+		CollisionSuffix:   DefaultCollisionSuffix,
+		customSources:     cli.NewStringSlice(),
+		whitelist:         cli.NewStringSlice(),
+		blacklist:         cli.NewStringSlice(),
+		levels:            cli.NewStringSlice(),
+		includes:          cli.NewStringSlice(),
+		excludes:          cli.NewStringSlice(),
+		initialisms:       cli.NewStringSlice(),
+		typeOverrides:     cli.NewStringSlice(),
+		generators:        cli.NewStringSlice(),
+		pluginOptions:     cli.NewStringSlice(),
+		registry:          registry,
 	}, nil
 }
 
+// RegisterExternalPlugins registers the external output plugins found on the PATH (see
+// package external), so that they can be enabled like the builtin ones. It must be called
+// before CLIFlags. External plugins cannot replace a plugin that is already registered.
+func (c *Config) RegisterExternalPlugins() error {
+	for _, x := range external.Discover() {
+		if _, err := c.registry.Get(x().ID()); err == nil {
+			continue
+		}
+
+		err := c.registry.Register(x)
+		if err != nil {
+			return fmt.Errorf("could not register external output plugin: %v", err)
+		}
+	}
+
+	return nil
+}
+
 // CLIFlags is a helper to automatically set fields within a Config object
 // based on CLI flags using the github.com/urfave/cli framework.
 func (c *Config) CLIFlags() []cli.Flag {
@@ -67,34 +224,146 @@ func (c *Config) CLIFlags() []cli.Flag {
 	}
 
 	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config",
+			Usage:       "Path to an ecsgen.yml project file. Flags and environment variables override the values in the file.",
+			EnvVars:     []string{"ECSGEN_CONFIG"},
+			Destination: &c.ProjectFile,
+		},
 		&cli.StringFlag{
 			Name:        "source-file",
-			Usage:       "Path to the generated ecs_flat.yml file containing ECS definitions.",
+			Usage:       "Path to the generated ecs_flat.yml file containing ECS definitions, or the ECS schemas directory when using the schemas source format.",
 			EnvVars:     []string{"ECSGEN_SOURCE_FILE"},
-			Required:    true,
 			Destination: &c.SourceFile,
 		},
+		&cli.StringFlag{
+			Name:        "source-format",
+			Usage:       fmt.Sprintf("Format of the source file. Possible values: %s", strings.Join(SourceFormats, ", ")),
+			EnvVars:     []string{"ECSGEN_SOURCE_FORMAT"},
+			Value:       SourceFormatFlat,
+			Destination: &c.SourceFormat,
+		},
+		&cli.StringSliceFlag{
+			Name:        "custom-source-file",
+			Usage:       "Path to an additional schema file that is merged on top of the source file. Uses the source format unless prefixed with another format (i.e. fields:path/to/fields.yml). (Can be used multiple times).",
+			EnvVars:     []string{"ECSGEN_CUSTOM_SOURCE_FILE"},
+			Value:       c.customSources,
+			Destination: c.customSources,
+		},
+		&cli.BoolFlag{
+			Name:        "allow-overrides",
+			Usage:       "Allow custom source files to redefine fields that were defined by a previous source.",
+			EnvVars:     []string{"ECSGEN_ALLOW_OVERRIDES"},
+			Destination: &c.AllowOverrides,
+		},
+		&cli.StringFlag{
+			Name:        "collision-strategy",
+			Usage:       fmt.Sprintf("How to resolve a scalar field that is also used as the parent of other fields. Possible values: %s", strings.Join(CollisionStrategies, ", ")),
+			EnvVars:     []string{"ECSGEN_COLLISION_STRATEGY"},
+			Value:       CollisionStrategyError,
+			Destination: &c.CollisionStrategy,
+		},
+		&cli.StringFlag{
+			Name:        "collision-suffix",
+			Usage:       "Suffix appended to the name of a scalar field that is moved by the suffix collision strategy.",
+			EnvVars:     []string{"ECSGEN_COLLISION_SUFFIX"},
+			Value:       DefaultCollisionSuffix,
+			Destination: &c.CollisionSuffix,
+		},
 		&cli.StringSliceFlag{
 			Name:        "whitelist",
-			Usage:       "Regular expression that denotes which ECS keys to allow into the model. (Can be used multiple times).",
+			Usage:       "Regular expression (or glob or query, when prefixed with \"glob:\" or \"query:\") that denotes which ECS keys to allow into the model. (Can be used multiple times).",
 			EnvVars:     []string{"ECSGEN_WHITELIST_VALUE"},
 			Value:       c.whitelist,
 			Destination: c.whitelist,
 		},
 		&cli.StringSliceFlag{
 			Name:        "blacklist",
-			Usage:       "Regular expression that denotes which ECS keys to explicitly forbid into the model. (Can be used multiple times).",
+			Usage:       "Regular expression (or glob or query, when prefixed with \"glob:\" or \"query:\") that denotes which ECS keys to explicitly forbid into the model. (Can be used multiple times).",
 			EnvVars:     []string{"ECSGEN_BLACKLIST_VALUE"},
 			Value:       c.blacklist,
 			Destination: c.blacklist,
 		},
+		&cli.StringSliceFlag{
+			Name:        "include-fieldset",
+			Usage:       "Only allow the fields of the given ECS fieldsets into the model (i.e. dns,process). (Can be used multiple times).",
+			EnvVars:     []string{"ECSGEN_INCLUDE_FIELDSET"},
+			Value:       c.includes,
+			Destination: c.includes,
+		},
+		&cli.StringSliceFlag{
+			Name:        "exclude-fieldset",
+			Usage:       "Forbid the fields of the given ECS fieldsets from the model. (Can be used multiple times).",
+			EnvVars:     []string{"ECSGEN_EXCLUDE_FIELDSET"},
+			Value:       c.excludes,
+			Destination: c.excludes,
+		},
+		&cli.StringSliceFlag{
+			Name:        "level",
+			Usage:       fmt.Sprintf("Only allow fields of the given ECS levels into the model (i.e. core,extended). Possible values: %s (Can be used multiple times).", strings.Join(ecsgen.Levels, ", ")),
+			EnvVars:     []string{"ECSGEN_LEVEL"},
+			Value:       c.levels,
+			Destination: c.levels,
+		},
+		&cli.StringSliceFlag{
+			Name:        "type-override",
+			Usage:       "Override the ECS type of a field, in the form key=type (i.e. event.duration=keyword). (Can be used multiple times).",
+			EnvVars:     []string{"ECSGEN_TYPE_OVERRIDE"},
+			Value:       c.typeOverrides,
+			Destination: c.typeOverrides,
+		},
+		&cli.StringSliceFlag{
+			Name:        "initialism",
+			Usage:       "Additional initialism to keep capitalized in generated identifiers (i.e. K8S). (Can be used multiple times).",
+			EnvVars:     []string{"ECSGEN_INITIALISM"},
+			Value:       c.initialisms,
+			Destination: c.initialisms,
+		},
+		&cli.BoolFlag{
+			Name:        "explain-filters",
+			Usage:       "Log whether each ECS key was kept or dropped by the filters, and which rule decided it.",
+			EnvVars:     []string{"ECSGEN_EXPLAIN_FILTERS"},
+			Destination: &c.ExplainFilters,
+		},
+		&cli.BoolFlag{
+			Name:        "check",
+			Usage:       "Compare the output of the plugins with the files on disk instead of writing it, printing a diff and failing if any are out of date.",
+			EnvVars:     []string{"ECSGEN_CHECK"},
+			Destination: &c.Check,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Run the plugins without writing anything, and list the files that would be written.",
+			EnvVars:     []string{"ECSGEN_DRY_RUN"},
+			Destination: &c.DryRun,
+		},
+		&cli.BoolFlag{
+			Name:        "fail-fast",
+			Usage:       "Run the plugins one at a time, stopping at the first one that fails, instead of running them concurrently and reporting every failure.",
+			EnvVars:     []string{"ECSGEN_FAIL_FAST"},
+			Destination: &c.FailFast,
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Usage:       fmt.Sprintf("Where the plugins write the generated files: a directory, stdout, or an archive in the form kind:path (i.e. zip:generated.zip). Possible kinds: %s", strings.Join(OutputKinds, ", ")),
+			EnvVars:     []string{"ECSGEN_OUTPUT"},
+			Value:       DefaultOutput,
+			Destination: &c.Output,
+		},
 		&cli.StringSliceFlag{
 			Name:        "output-plugin",
-			Usage:       fmt.Sprintf("Enable an output generator plugin. Can be used multiple times. Possible values: %s", strings.Join(pluginNames, ", ")),
+			Usage:       fmt.Sprintf("Enable an output generator plugin. Append :name to enable another named instance of a plugin (i.e. gostruct:api). Can be used multiple times. Possible values: %s", strings.Join(pluginNames, ", ")),
 			EnvVars:     []string{"ECSGEN_OUTPUT_PLUGIN"},
 			Value:       c.generators,
 			Destination: c.generators,
 		},
+		&cli.StringSliceFlag{
+			Name:        "plugin-option",
+			Usage:       "Set an option of a named output plugin instance, in the form plugin:name.option=value (i.e. gostruct:api.package-name=api). (Can be used multiple times).",
+			EnvVars:     []string{"ECSGEN_PLUGIN_OPTION"},
+			Value:       c.pluginOptions,
+			Destination: c.pluginOptions,
+		},
 	}
 
 	flags = append(flags, c.registry.CLIFlags()...)
@@ -105,23 +374,32 @@ func (c *Config) CLIFlags() []cli.Flag {
 func (c *Config) Validate() error {
 	// Check the Source Directory
 	// is it assigned?
-	if c.SourceFile == "" {
+	if c.SourceFile == "" && c.sources == nil {
 		return ErrInvalidSourceFile
 	}
 
-	// Is it a valid path?
-	dir, err := os.Stat(c.SourceFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("could not locate the source file: %v", err)
-		}
+	// is it a format we know how to load?
+	if !validSourceFormat(c.SourceFormat) {
+		return fmt.Errorf("%s is not a valid source format. valid options: %s", c.SourceFormat, strings.Join(SourceFormats, ", "))
+	}
 
-		return fmt.Errorf("error locating specified source file: %v", err)
+	// Are all of the sources valid?
+	for _, source := range c.Sources() {
+		err := validateSource(source)
+		if err != nil {
+			return err
+		}
 	}
 
-	// is it a valid file?
-	if dir.IsDir() {
-		return fmt.Errorf("specified source file path was a directory, not a file")
+	// is the collision strategy known?
+	switch c.CollisionStrategy {
+	case CollisionStrategyError:
+	case CollisionStrategySuffix:
+		if c.CollisionSuffix == "" || strings.Contains(c.CollisionSuffix, ".") {
+			return fmt.Errorf("collision suffix %q must be non-empty and cannot contain a \".\"", c.CollisionSuffix)
+		}
+	default:
+		return fmt.Errorf("%s is not a valid collision strategy. valid options: %s", c.CollisionStrategy, strings.Join(CollisionStrategies, ", "))
 	}
 
 	// check to make sure the whitelist is valid
@@ -134,47 +412,369 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("error parsing blacklist parameter: %v", err)
 	}
 
-	// verify output plugins
-	if len(c.generators.Value()) == 0 {
+	// are the levels known?
+	for _, level := range c.Levels() {
+		if !contains(ecsgen.Levels, level) {
+			return fmt.Errorf("%s is not a valid level. valid options: %s", level, strings.Join(ecsgen.Levels, ", "))
+		}
+	}
+
+	// are the type overrides well formed?
+	if _, err := c.TypeOverrides(); err != nil {
+		return err
+	}
+
+	// is the output well formed, and can it be used with the selected mode?
+	target, err := c.OutputTarget()
+	if err != nil {
+		return err
+	}
+
+	if c.Check && c.DryRun {
+		return fmt.Errorf("check and dry-run cannot be used together")
+	}
+
+	if c.Check && target.Kind != OutputKindDir {
+		return fmt.Errorf("check can only compare against a directory output, not %s", target)
+	}
+
+	// verify output plugins. a Config created with New can be used to only load the schema.
+	if len(c.generators.Value()) == 0 && c.sources == nil {
 		return fmt.Errorf("did not specify any output generators")
 	}
 
 	// ensure any specified output plugins actually exist
-	for _, x := range c.generators.Value() {
-		generator, err := c.registry.Get(x)
+	generators, err := c.Generators()
+	if err != nil {
+		return err
+	}
+
+	// check that the configuration is valid for any enabled plugin
+	for _, generator := range generators {
+		err := generator.Validate()
 		if err != nil {
-			pluginNames := []string{}
-			for _, x := range c.registry.All() {
-				pluginNames = append(pluginNames, x.ID())
-			}
-			return fmt.Errorf("%s is not a valid plugin name. valid options: %s", x, strings.Join(pluginNames, ", "))
+			return fmt.Errorf("error in output plugin %s: %v", generator.ID(), err)
 		}
 
-		// check that the configuration is valid for any enabled plugin
-		err = generator.Validate()
-		if err != nil {
+		if _, _, err := generatorFilters(generator); err != nil {
 			return fmt.Errorf("error in output plugin %s: %v", generator.ID(), err)
 		}
+
+		err = c.validateRequiredOptions(generator)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// validateRequiredOptions checks that the required options of an enabled output plugin were
+// set. The CLI does not enforce them, as the flags of every plugin are added whether or not it
+// is enabled. Named instances check their own options when they are created.
+func (c *Config) validateRequiredOptions(g generator.Generator) error {
+	if c.isSet == nil {
+		return nil
+	}
+
+	id, name := generator.SplitInstance(g.ID())
+	if name != "" {
+		return nil
+	}
+
+	for _, option := range generator.RequiredOptions(g) {
+		flagName := generator.OptionFlagName(id, option)
+		if !c.isSet(flagName) {
+			return fmt.Errorf("output plugin %s requires the %s option (--%s)", id, option, flagName)
+		}
+	}
+
+	return nil
+}
+
+// Levels returns the ECS levels that fields must have to be allowed into the model.
+// An empty list allows every level.
+func (c *Config) Levels() []string {
+	return splitList(c.levels.Value())
+}
+
+// IncludeFieldsets returns the names of the fieldsets whose fields are allowed into
+// the model. An empty list allows every fieldset.
+func (c *Config) IncludeFieldsets() []string {
+	return splitList(c.includes.Value())
+}
+
+// ExcludeFieldsets returns the names of the fieldsets whose fields are forbidden from the model.
+func (c *Config) ExcludeFieldsets() []string {
+	return splitList(c.excludes.Value())
+}
+
+// Initialisms returns the additional initialisms that should be kept capitalized in identifiers.
+func (c *Config) Initialisms() []string {
+	return splitList(c.initialisms.Value())
+}
+
+// TypeOverrides returns the ECS types that should replace the type of a field, keyed by ECS key.
+func (c *Config) TypeOverrides() (map[string]string, error) {
+	ret := map[string]string{}
+
+	for _, v := range c.typeOverrides.Value() {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("type override %q must be in the form key=type", v)
+		}
+
+		if !contains(ecsgen.FieldTypes, parts[1]) {
+			return nil, fmt.Errorf("type override %q uses unknown type %s. valid options: %s", v, parts[1], strings.Join(ecsgen.FieldTypes, ", "))
+		}
+
+		ret[parts[0]] = parts[1]
+	}
+
+	return ret, nil
+}
+
+// Sources returns the ordered list of schema sources that should be loaded. The
+// first element is always the source file, followed by any custom source files in the
+// order they were specified. Later sources are merged on top of earlier ones. A Config
+// created with New returns the sources it was given.
+func (c *Config) Sources() []Source {
+	if c.sources != nil {
+		return c.sources
+	}
+
+	ret := []Source{
+		{
+			Path:   c.SourceFile,
+			Format: c.SourceFormat,
+		},
+	}
+
+	for _, x := range c.customSources.Value() {
+		ret = append(ret, parseSource(x, c.SourceFormat))
+	}
+
+	return ret
+}
+
 // Generators returns the set of enabled generators for the config.
 func (c *Config) Generators() ([]generator.Generator, error) {
+	// instances are configured when they're created, so only create them once
+	if c.instances != nil {
+		return c.instances, nil
+	}
+
+	options, err := c.instanceOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	ret := []generator.Generator{}
+	enabled := map[string]bool{}
 
 	for _, x := range c.generators.Value() {
-		generator, err := c.registry.Get(x)
-		if err != nil {
+		if enabled[x] {
+			return nil, fmt.Errorf("output plugin %s was enabled more than once", x)
+		}
+
+		enabled[x] = true
+
+		id, name := generator.SplitInstance(x)
+		if _, err := c.registry.Get(id); err != nil {
 			pluginNames := []string{}
 			for _, x := range c.registry.All() {
 				pluginNames = append(pluginNames, x.ID())
 			}
-			return ret, fmt.Errorf("%s plugin could not be loaded. valid options: %s", x, strings.Join(pluginNames, ", "))
+			return nil, fmt.Errorf("%s is not a valid plugin name. valid options: %s", x, strings.Join(pluginNames, ", "))
+		}
+
+		// the default instance is configured by the --opt-<plugin>-* flags
+		if name == "" {
+			g, err := c.registry.Get(id)
+			if err != nil {
+				return nil, err
+			}
+
+			ret = append(ret, g)
+			continue
 		}
-		ret = append(ret, generator)
+
+		g, err := generator.NewInstance(c.registry, x, options[x])
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, g)
 	}
 
+	// options for instances that are not enabled are most likely a typo
+	for spec := range options {
+		if !enabled[spec] {
+			return nil, fmt.Errorf("options were given for output plugin %s, which is not enabled", spec)
+		}
+	}
+
+	c.instances = ret
+
 	return ret, nil
 }
+
+// GeneratorRoot returns the tree an output plugin should be executed with. If the plugin has
+// include or exclude rules, this is a read-only view of root that only holds the fields that
+// pass them (see ecsgen.Root.View). Otherwise, root is returned as is.
+func (c *Config) GeneratorRoot(g generator.Generator, root *ecsgen.Root) (*ecsgen.Root, error) {
+	include, exclude, err := generatorFilters(g)
+	if err != nil {
+		return nil, fmt.Errorf("error in output plugin %s: %v", g.ID(), err)
+	}
+
+	if include.Empty() && exclude.Empty() {
+		return root, nil
+	}
+
+	view := root.View(func(n *ecsgen.Node) bool {
+		if !include.Empty() && !include.Match(n.Path, n.Definition) {
+			return false
+		}
+
+		return exclude.Empty() || !exclude.Match(n.Path, n.Definition)
+	})
+
+	return view, nil
+}
+
+// generatorFilters parses the include and exclude rules of an output plugin.
+func generatorFilters(g generator.Generator) (FilterList, FilterList, error) {
+	filters := generator.FiltersOf(g)
+
+	include, err := parseFilterList(filters.Include)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating rule for include value: %v", err)
+	}
+
+	exclude, err := parseFilterList(filters.Exclude)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating rule for exclude value: %v", err)
+	}
+
+	return include, exclude, nil
+}
+
+// instanceOptions returns the options of named output plugin instances, keyed by instance
+// (i.e. "gostruct:api"). Options from the project file come before options from the command
+// line, so the command line wins when both set the same option.
+func (c *Config) instanceOptions() (map[string][]string, error) {
+	ret := map[string][]string{}
+
+	values := append([]string{}, c.projectPluginOptions...)
+	values = append(values, c.pluginOptions.Value()...)
+
+	for _, v := range values {
+		// plugin IDs and instance names cannot contain a ".", so the first one ends the instance
+		idx := strings.Index(v, ".")
+		if idx == -1 {
+			return nil, fmt.Errorf("plugin option %q must be in the form plugin:name.option=value", v)
+		}
+
+		spec := v[:idx]
+		if _, name := generator.SplitInstance(spec); name == "" {
+			return nil, fmt.Errorf("plugin option %q must name an instance (i.e. %s:name). use --%s instead", v, spec, generator.OptionFlagName(spec, strings.SplitN(v[idx+1:], "=", 2)[0]))
+		}
+
+		ret[spec] = append(ret[spec], v[idx+1:])
+	}
+
+	return ret, nil
+}
+
+// parseSource parses a source specification of the form "[format:]path". If the
+// prefix is not a known format, the whole value is treated as the path.
+func parseSource(value string, defaultFormat string) Source {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) == 2 && validSourceFormat(parts[0]) {
+		return Source{
+			Path:   parts[1],
+			Format: parts[0],
+		}
+	}
+
+	return Source{
+		Path:   value,
+		Format: defaultFormat,
+	}
+}
+
+func validSourceFormat(format string) bool {
+	for _, x := range SourceFormats {
+		if x == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitList flattens flag values that can be given as comma separated lists
+// (i.e. --level core,extended), dropping any empty elements.
+func splitList(values []string) []string {
+	ret := []string{}
+
+	for _, value := range values {
+		for _, elm := range strings.Split(value, ",") {
+			elm = strings.TrimSpace(elm)
+			if elm != "" {
+				ret = append(ret, elm)
+			}
+		}
+	}
+
+	return ret
+}
+
+// contains returns true if val is an element of list.
+func contains(list []string, val string) bool {
+	for _, x := range list {
+		if x == val {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateSource(source Source) error {
+	// Is it a valid path?
+	dir, err := fs.Stat(source.FileSystem(), source.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("could not locate the source file: %v", err)
+		}
+
+		return fmt.Errorf("error locating specified source file: %v", err)
+	}
+
+	if !validSourceFormat(source.Format) {
+		return fmt.Errorf("%s is not a valid source format for %s. valid options: %s", source.Format, source.Path, strings.Join(SourceFormats, ", "))
+	}
+
+	// Beats fields can be a single file or a directory of them
+	if source.Format == SourceFormatFields {
+		return nil
+	}
+
+	// the schemas format is a directory of fieldset files
+	if source.Format == SourceFormatSchemas {
+		if !dir.IsDir() {
+			return fmt.Errorf("specified source path %s was a file, not a schemas directory", source.Path)
+		}
+
+		return nil
+	}
+
+	// is it a valid file?
+	if dir.IsDir() {
+		return fmt.Errorf("specified source file path %s was a directory, not a file", source.Path)
+	}
+
+	return nil
+}
@@ -3,10 +3,104 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"strings"
+
+	"github.com/gen0cide/ecsgen"
 )
 
-// FilterList is a type alias that is used to evaluate possible
-type FilterList []*regexp.Regexp
+const (
+	// FilterPrefixRegexp marks a filter rule as a regular expression that is matched against ECS keys.
+	// Rules without a prefix are regular expressions as well.
+	FilterPrefixRegexp = "re:"
+
+	// FilterPrefixGlob marks a filter rule as a dotted glob pattern that is matched against ECS keys,
+	// such as "process.*" or "**.geo.**". See ecsgen.Query for the pattern syntax.
+	FilterPrefixGlob = "glob:"
+
+	// FilterPrefixQuery marks a filter rule as an ecsgen query (see ecsgen.Query).
+	FilterPrefixQuery = "query:"
+)
+
+// FilterRule is a single rule within a FilterList.
+type FilterRule interface {
+	// Match returns true if the definition with the given ECS key matches the rule.
+	Match(id string, def *ecsgen.Definition) bool
+
+	// String returns the rule as it was specified.
+	String() string
+}
+
+// regexpRule matches ECS keys against a regular expression.
+type regexpRule struct {
+	value string
+	rx    *regexp.Regexp
+}
+
+// Match implements the FilterRule interface.
+func (r *regexpRule) Match(id string, _ *ecsgen.Definition) bool {
+	return r.rx.MatchString(id)
+}
+
+// String implements the FilterRule interface.
+func (r *regexpRule) String() string {
+	return r.value
+}
+
+// queryRule matches definitions against an ecsgen query. It is also used for glob rules.
+type queryRule struct {
+	value string
+	query *ecsgen.Query
+}
+
+// Match implements the FilterRule interface.
+func (r *queryRule) Match(id string, def *ecsgen.Definition) bool {
+	return r.query.MatchDefinition(id, def)
+}
+
+// String implements the FilterRule interface.
+func (r *queryRule) String() string {
+	return r.value
+}
+
+// ParseFilterRule creates a FilterRule from a whitelist or blacklist value. Values prefixed
+// with "glob:" are dotted glob patterns and values prefixed with "query:" are ecsgen queries,
+// while values prefixed with "re:" - or without a prefix - are regular expressions.
+func ParseFilterRule(value string) (FilterRule, error) {
+	switch {
+	case strings.HasPrefix(value, FilterPrefixGlob):
+		pattern := strings.TrimPrefix(value, FilterPrefixGlob)
+
+		// globs are queries without predicates
+		if pattern == "" || strings.ContainsAny(pattern, "[]") {
+			return nil, fmt.Errorf("glob pattern %q must be non-empty and cannot contain predicates", pattern)
+		}
+
+		q, err := ecsgen.ParseQuery(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return &queryRule{value: value, query: q}, nil
+	case strings.HasPrefix(value, FilterPrefixQuery):
+		q, err := ecsgen.ParseQuery(strings.TrimPrefix(value, FilterPrefixQuery))
+		if err != nil {
+			return nil, err
+		}
+
+		return &queryRule{value: value, query: q}, nil
+	default:
+		rx, err := regexp.Compile(strings.TrimPrefix(value, FilterPrefixRegexp))
+		if err != nil {
+			return nil, err
+		}
+
+		return &regexpRule{value: value, rx: rx}, nil
+	}
+}
+
+// FilterList is a list of rules that is used to evaluate whether a definition
+// should be allowed into the model.
+type FilterList []FilterRule
 
 // Whitelist is used to generate a Whitelist from a given Config object.
 func (c *Config) Whitelist() (FilterList, error) {
@@ -17,11 +111,11 @@ func (c *Config) Whitelist() (FilterList, error) {
 	ret := FilterList{}
 
 	for _, v := range c.whitelist.Value() {
-		rx, err := regexp.Compile(v)
+		rule, err := ParseFilterRule(v)
 		if err != nil {
-			return ret, fmt.Errorf("error creating regexp for whitelist value \"%s\": %v", v, err)
+			return ret, fmt.Errorf("error creating rule for whitelist value \"%s\": %v", v, err)
 		}
-		ret = append(ret, rx)
+		ret = append(ret, rule)
 	}
 
 	return ret, nil
@@ -37,26 +131,41 @@ func (c *Config) Blacklist() (FilterList, error) {
 	ret := FilterList{}
 
 	for _, v := range c.blacklist.Value() {
-		rx, err := regexp.Compile(v)
+		rule, err := ParseFilterRule(v)
 		if err != nil {
-			return ret, fmt.Errorf("error creating regexp for blacklist value \"%s\": %v", v, err)
+			return ret, fmt.Errorf("error creating rule for blacklist value \"%s\": %v", v, err)
 		}
-		ret = append(ret, rx)
+		ret = append(ret, rule)
 	}
 
 	return ret, nil
 }
 
-// Match is used to check an arbitrary string against a filter list.
-func (w FilterList) Match(val string) bool {
+// parseFilterList creates a FilterList from a list of rule values.
+func parseFilterList(values []string) (FilterList, error) {
+	ret := FilterList{}
+
+	for _, v := range values {
+		rule, err := ParseFilterRule(v)
+		if err != nil {
+			return ret, fmt.Errorf("\"%s\": %v", v, err)
+		}
+		ret = append(ret, rule)
+	}
+
+	return ret, nil
+}
+
+// Match is used to check a definition and its ECS key against a filter list.
+func (w FilterList) Match(id string, def *ecsgen.Definition) bool {
 	// short circuit for an empty whitelist - allow all
 	if len(w) == 0 {
 		return true
 	}
 
 	passed := false
-	for _, matcher := range w {
-		if matcher.MatchString(val) {
+	for _, rule := range w {
+		if rule.Match(id, def) {
 			passed = true
 			break
 		}
@@ -65,6 +174,20 @@ func (w FilterList) Match(val string) bool {
 	return passed
 }
 
+// Matches returns every rule in the filter list that matches a definition and its ECS key,
+// in the order the rules were given.
+func (w FilterList) Matches(id string, def *ecsgen.Definition) []FilterRule {
+	ret := []FilterRule{}
+
+	for _, rule := range w {
+		if rule.Match(id, def) {
+			ret = append(ret, rule)
+		}
+	}
+
+	return ret
+}
+
 // Empty is a convienience function that is used to check if a FilterList is populated.
 func (w FilterList) Empty() bool {
 	return len(w) == 0
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/gen0cide/ecsgen"
+)
+
+func TestParseFilterRule(t *testing.T) {
+	keyword := &ecsgen.Definition{Type: "keyword"}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+		match   []string
+		noMatch []string
+	}{
+		{name: "regexp without prefix", value: `^process\.`, match: []string{"process.pid"}, noMatch: []string{"parent.process"}},
+		{name: "regexp prefix", value: `re:\.pid$`, match: []string{"process.pid", "process.parent.pid"}, noMatch: []string{"process.pids"}},
+		{name: "regexps are not anchored", value: "pid", match: []string{"process.pid", "pids"}},
+		{name: "invalid regexp", value: "re:[a", wantErr: true},
+		{name: "glob", value: "glob:process.*", match: []string{"process.pid"}, noMatch: []string{"process.parent.pid", "process"}},
+		{name: "double star glob", value: "glob:**.geo.**", match: []string{"client.geo.ip", "geo", "source.geo"}, noMatch: []string{"geoip.ip"}},
+		{name: "globs match whole elements", value: "glob:process.pid", match: []string{"process.pid"}, noMatch: []string{"process.pids", "process.pid.x"}},
+		{name: "empty glob", value: "glob:", wantErr: true},
+		{name: "glob with predicates", value: "glob:process.*[type=keyword]", wantErr: true},
+		{name: "invalid glob", value: "glob:process..pid", wantErr: true},
+		{name: "query", value: "query:process.*[type=keyword]", match: []string{"process.name"}, noMatch: []string{"host.name"}},
+		{name: "invalid query", value: "query:**[colour=blue]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseFilterRule(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilterRule(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if rule.String() != tt.value {
+				t.Errorf("String() = %q, want %q", rule.String(), tt.value)
+			}
+
+			for _, id := range tt.match {
+				if !rule.Match(id, keyword) {
+					t.Errorf("%q does not match %s", tt.value, id)
+				}
+			}
+
+			for _, id := range tt.noMatch {
+				if rule.Match(id, keyword) {
+					t.Errorf("%q matches %s", tt.value, id)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterListMatch(t *testing.T) {
+	list, err := parseFilterList([]string{"glob:process.*", `re:\.ip$`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id          string
+		want        bool
+		wantMatches int
+	}{
+		{id: "process.pid", want: true, wantMatches: 1},
+		{id: "process.ip", want: true, wantMatches: 2},
+		{id: "client.nat.ip", want: true, wantMatches: 1},
+		{id: "host.name", want: false, wantMatches: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := list.Match(tt.id, nil); got != tt.want {
+				t.Errorf("Match(%s) = %v, want %v", tt.id, got, tt.want)
+			}
+
+			if got := list.Matches(tt.id, nil); len(got) != tt.wantMatches {
+				t.Errorf("Matches(%s) = %v, want %d rules", tt.id, got, tt.wantMatches)
+			}
+		})
+	}
+
+	if !(FilterList{}).Match("anything", nil) {
+		t.Error("empty FilterList does not match everything")
+	}
+}
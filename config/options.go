@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gen0cide/ecsgen/generator"
+	"github.com/urfave/cli"
+)
+
+// Filters holds the rules that select which ECS keys are allowed into the model. They
+// are the same as the CLI flags of the same names.
+type Filters struct {
+	Whitelist        []string
+	Blacklist        []string
+	IncludeFieldsets []string
+	ExcludeFieldsets []string
+	Levels           []string
+}
+
+// Options holds the settings of a Config that is created in code rather than from CLI flags.
+type Options struct {
+	// Sources are loaded in order, with later sources merged on top of earlier ones. At
+	// least one is required.
+	Sources []Source
+
+	AllowOverrides    bool
+	CollisionStrategy string
+	CollisionSuffix   string
+	Filters           Filters
+
+	// TypeOverrides replace the ECS type of a field, keyed by ECS key.
+	TypeOverrides map[string]string
+	Initialisms   []string
+
+	// Generators are executed in order. They are configured by the caller, for example
+	// with the typed options of the builtin generators, rather than with CLI flags.
+	Generators []generator.Generator
+
+	// FailFast executes the generators one at a time, in order, stopping at the first failure.
+	FailFast bool
+}
+
+// New creates a Config from Options. Settings that are not set use the same defaults as
+// the CLI flags. The Config is validated before it is returned.
+func New(opts Options) (*Config, error) {
+	c, err := NewEmptyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	c.AllowOverrides = opts.AllowOverrides
+	c.FailFast = opts.FailFast
+
+	if opts.CollisionStrategy != "" {
+		c.CollisionStrategy = opts.CollisionStrategy
+	}
+
+	if opts.CollisionSuffix != "" {
+		c.CollisionSuffix = opts.CollisionSuffix
+	}
+
+	c.sources = opts.Sources
+	if len(c.sources) == 0 {
+		return nil, ErrInvalidSourceFile
+	}
+
+	c.whitelist = cli.NewStringSlice(opts.Filters.Whitelist...)
+	c.blacklist = cli.NewStringSlice(opts.Filters.Blacklist...)
+	c.includes = cli.NewStringSlice(opts.Filters.IncludeFieldsets...)
+	c.excludes = cli.NewStringSlice(opts.Filters.ExcludeFieldsets...)
+	c.levels = cli.NewStringSlice(opts.Filters.Levels...)
+	c.initialisms = cli.NewStringSlice(opts.Initialisms...)
+
+	// sort the overrides so they are applied in a stable order
+	overrides := []string{}
+	for id, fieldType := range opts.TypeOverrides {
+		overrides = append(overrides, fmt.Sprintf("%s=%s", id, fieldType))
+	}
+
+	sort.Strings(overrides)
+
+	c.typeOverrides = cli.NewStringSlice(overrides...)
+
+	// the generators are already configured, so they are used as is
+	ids := []string{}
+	for _, g := range opts.Generators {
+		if contains(ids, g.ID()) {
+			return nil, fmt.Errorf("output plugin %s was enabled more than once", g.ID())
+		}
+
+		ids = append(ids, g.ID())
+	}
+
+	c.generators = cli.NewStringSlice(ids...)
+	c.instances = append([]generator.Generator{}, opts.Generators...)
+
+	err = c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
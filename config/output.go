@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gen0cide/ecsgen/generator"
+)
+
+const (
+	// OutputKindDir writes the generated files to a directory. Paths used by the
+	// output plugins are relative to the directory.
+	OutputKindDir = "dir"
+
+	// OutputKindStdout writes the contents of every generated file to stdout.
+	OutputKindStdout = "stdout"
+
+	// OutputKindTar writes the generated files to a tar archive.
+	OutputKindTar = "tar"
+
+	// OutputKindZip writes the generated files to a zip archive.
+	OutputKindZip = "zip"
+
+	// DefaultOutput writes the generated files relative to the working directory.
+	DefaultOutput = "."
+)
+
+var (
+	// OutputKinds is the list of supported places to write the generated files to.
+	OutputKinds = []string{
+		OutputKindDir,
+		OutputKindStdout,
+		OutputKindTar,
+		OutputKindZip,
+	}
+)
+
+// OutputTarget describes where the generated files should be written.
+type OutputTarget struct {
+	Kind string
+
+	// Path is the directory or archive file. It is empty for stdout.
+	Path string
+}
+
+// ParseOutputTarget parses an output specification. The specification is either "stdout" (or "-"),
+// a kind and a path separated by a colon (i.e. "zip:generated.zip"), or the path of a directory.
+func ParseOutputTarget(spec string) (OutputTarget, error) {
+	if spec == OutputKindStdout || spec == "-" {
+		return OutputTarget{Kind: OutputKindStdout}, nil
+	}
+
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) == 2 && contains(OutputKinds, parts[0]) {
+		if parts[0] == OutputKindStdout || parts[1] == "" {
+			return OutputTarget{}, fmt.Errorf("output %q must be in the form kind:path. valid kinds: %s", spec, strings.Join(OutputKinds, ", "))
+		}
+
+		return OutputTarget{Kind: parts[0], Path: parts[1]}, nil
+	}
+
+	if spec == "" {
+		spec = DefaultOutput
+	}
+
+	return OutputTarget{Kind: OutputKindDir, Path: spec}, nil
+}
+
+// String implements the fmt.Stringer interface.
+func (t OutputTarget) String() string {
+	if t.Kind == OutputKindStdout {
+		return t.Kind
+	}
+
+	return t.Kind + ":" + t.Path
+}
+
+// Open creates the generator.Output for the target. The returned function must be called
+// once every generator has been executed, to complete any archive and close its file.
+func (t OutputTarget) Open() (generator.Output, func() error, error) {
+	noop := func() error { return nil }
+
+	switch t.Kind {
+	case OutputKindDir:
+		return generator.NewDirOutput(t.Path), noop, nil
+	case OutputKindStdout:
+		return generator.NewWriterOutput(os.Stdout), noop, nil
+	}
+
+	f, err := os.Create(t.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating output file: %v", err)
+	}
+
+	var archive interface {
+		generator.Output
+		Close() error
+	}
+
+	if t.Kind == OutputKindTar {
+		archive = generator.NewTarOutput(f)
+	} else {
+		archive = generator.NewZipOutput(f)
+	}
+
+	closer := func() error {
+		err := archive.Close()
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("error writing %s: %v", t.Path, err)
+		}
+
+		return f.Close()
+	}
+
+	return archive, closer, nil
+}
+
+// OutputTarget returns where the generated files should be written.
+func (c *Config) OutputTarget() (OutputTarget, error) {
+	return ParseOutputTarget(c.Output)
+}
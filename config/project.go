@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-ucfg/yaml"
+	"github.com/gen0cide/ecsgen/generator"
+	"github.com/urfave/cli"
+)
+
+// projectPluginsKey is the key within a project file that holds the options of each output plugin.
+const projectPluginsKey = "plugins"
+
+// ApplyProjectFile reads the project file given with --config (if any) and uses it to set every
+// flag that was not set on the command line or through an environment variable. The keys of the
+// project file are the names of the flags, with the options of each output plugin nested under
+// "plugins" and keyed by the plugin ID or named instance (i.e. "gostruct:api"):
+//
+//	source-file: ecs_flat.yml
+//	whitelist:
+//	  - "glob:process.**"
+//	type-override:
+//	  event.duration: keyword
+//	output-plugin: [gostruct]
+//	plugins:
+//	  gostruct:
+//	    package-name: ecs
+//
+// Lists set a flag once per element, and maps (such as type-override) set it once per
+// "key=value" pair. Relative source and output paths are resolved against the directory of
+// the project file, so the file can be used from anywhere. It should be called once the flags
+// have been parsed, i.e. from a command's Before func. It also records which flags were set,
+// so that Validate can check the required options of the enabled output plugins.
+func (c *Config) ApplyProjectFile(ctx *cli.Context) error {
+	c.isSet = ctx.IsSet
+
+	if c.ProjectFile == "" {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(c.ProjectFile)
+	if err != nil {
+		return fmt.Errorf("error reading project file: %v", err)
+	}
+
+	config, err := yaml.NewConfig(contents)
+	if err != nil {
+		return fmt.Errorf("error reading project file %s: %v", c.ProjectFile, err)
+	}
+
+	data := map[string]interface{}{}
+
+	err = config.Unpack(&data)
+	if err != nil {
+		return fmt.Errorf("error unpacking project file %s: %v", c.ProjectFile, err)
+	}
+
+	// flatten the plugin options into the flag names they are exposed as
+	values := map[string]interface{}{}
+
+	for key, val := range data {
+		if key != projectPluginsKey {
+			values[key] = val
+			continue
+		}
+
+		plugins, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s in project file %s must be a map of output plugin IDs to options", projectPluginsKey, c.ProjectFile)
+		}
+
+		for spec, opts := range plugins {
+			id, instance := generator.SplitInstance(spec)
+			if _, err := c.registry.Get(id); err != nil {
+				return fmt.Errorf("unknown output plugin %s in project file %s", spec, c.ProjectFile)
+			}
+
+			options, ok := opts.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("options for output plugin %s in project file %s must be a map", spec, c.ProjectFile)
+			}
+
+			for name, opt := range options {
+				// named instances have no flags of their own
+				if instance != "" {
+					for _, val := range projectValues(opt) {
+						c.projectPluginOptions = append(c.projectPluginOptions, fmt.Sprintf("%s.%s=%s", spec, name, val))
+					}
+
+					continue
+				}
+
+				values[generator.OptionFlagName(id, name)] = opt
+			}
+		}
+	}
+
+	known := map[string]bool{}
+	for _, flag := range c.CLIFlags() {
+		for _, name := range flag.Names() {
+			known[name] = true
+		}
+	}
+
+	// apply the values in a stable order, so errors are deterministic
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !known[key] {
+			return fmt.Errorf("unknown option %s in project file %s", key, c.ProjectFile)
+		}
+
+		// flags and environment variables take precedence over the project file
+		if ctx.IsSet(key) {
+			continue
+		}
+
+		for _, val := range projectValues(values[key]) {
+			val = projectPath(filepath.Dir(c.ProjectFile), key, val)
+
+			err := ctx.Set(key, val)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for %s in project file %s: %v", val, key, c.ProjectFile, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// projectPath resolves the path in the value of a source or output flag against the directory
+// of the project file. Values of other flags, and absolute paths, are returned unchanged.
+func projectPath(dir string, key string, val string) string {
+	resolve := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+
+		return filepath.Join(dir, path)
+	}
+
+	switch key {
+	case "source-file":
+		return resolve(val)
+	case "custom-source-file":
+		// keep the format prefix, if any
+		parts := strings.SplitN(val, ":", 2)
+		if len(parts) == 2 && validSourceFormat(parts[0]) {
+			return parts[0] + ":" + resolve(parts[1])
+		}
+
+		return resolve(val)
+	case "output":
+		target, err := ParseOutputTarget(val)
+		if err != nil || target.Kind == OutputKindStdout {
+			return val
+		}
+
+		if val == target.Path {
+			return resolve(val)
+		}
+
+		return target.Kind + ":" + resolve(target.Path)
+	default:
+		return val
+	}
+}
+
+// projectValues converts a project file value into the flag values it represents.
+func projectValues(val interface{}) []string {
+	switch tval := val.(type) {
+	case nil:
+		return []string{}
+	case []interface{}:
+		ret := []string{}
+		for _, elm := range tval {
+			ret = append(ret, projectValues(elm)...)
+		}
+
+		return ret
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range tval {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		ret := []string{}
+		for _, key := range keys {
+			for _, elm := range projectValues(tval[key]) {
+				ret = append(ret, strings.Join([]string{key, elm}, "="))
+			}
+		}
+
+		return ret
+	default:
+		return []string{fmt.Sprint(tval)}
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestProjectPath(t *testing.T) {
+	dir := filepath.Join("project", "ecs")
+	abs, err := filepath.Abs("ecs_flat.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		val  string
+		want string
+	}{
+		{key: "source-file", val: "ecs_flat.yml", want: filepath.Join(dir, "ecs_flat.yml")},
+		{key: "source-file", val: "../schemas", want: filepath.Join("project", "schemas")},
+		{key: "source-file", val: abs, want: abs},
+		{key: "custom-source-file", val: "custom.yml", want: filepath.Join(dir, "custom.yml")},
+		{key: "custom-source-file", val: "fields:fields.yml", want: "fields:" + filepath.Join(dir, "fields.yml")},
+		{key: "custom-source-file", val: "fields:" + abs, want: "fields:" + abs},
+		{key: "output", val: ".", want: dir},
+		{key: "output", val: "generated", want: filepath.Join(dir, "generated")},
+		{key: "output", val: "dir:generated", want: "dir:" + filepath.Join(dir, "generated")},
+		{key: "output", val: "zip:generated.zip", want: "zip:" + filepath.Join(dir, "generated.zip")},
+		{key: "output", val: "stdout", want: "stdout"},
+		{key: "output", val: "-", want: "-"},
+		{key: "output", val: "zip:", want: "zip:"},
+		{key: "whitelist", val: "glob:process.*", want: "glob:process.*"},
+		{key: "opt-gostruct-output-dir", val: "ecs", want: "ecs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.val, func(t *testing.T) {
+			if got := projectPath(dir, tt.key, tt.val); got != tt.want {
+				t.Errorf("projectPath(%s, %s) = %s, want %s", tt.key, tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyProjectFilePaths(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ecsgen.yml")
+
+	err := ioutil.WriteFile(file, []byte(`
+source-file: ecs_flat.yml
+custom-source-file: [custom.yml, "fields:fields.yml"]
+output: zip:generated.zip
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantSources []Source
+		wantOutput  string
+	}{
+		{
+			name: "paths are relative to the project file",
+			wantSources: []Source{
+				{Path: filepath.Join(dir, "ecs_flat.yml"), Format: SourceFormatFlat},
+				{Path: filepath.Join(dir, "custom.yml"), Format: SourceFormatFlat},
+				{Path: filepath.Join(dir, "fields.yml"), Format: SourceFormatFields},
+			},
+			wantOutput: "zip:" + filepath.Join(dir, "generated.zip"),
+		},
+		{
+			name: "flags are relative to the working directory",
+			args: []string{"--source-file", "ecs_flat.yml", "--output", "out"},
+			wantSources: []Source{
+				{Path: "ecs_flat.yml", Format: SourceFormatFlat},
+				{Path: filepath.Join(dir, "custom.yml"), Format: SourceFormatFlat},
+				{Path: filepath.Join(dir, "fields.yml"), Format: SourceFormatFields},
+			},
+			wantOutput: "out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewEmptyConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			app := &cli.App{
+				Name:      "test",
+				Flags:     c.CLIFlags(),
+				Before:    c.ApplyProjectFile,
+				Action:    func(*cli.Context) error { return nil },
+				Writer:    ioutil.Discard,
+				ErrWriter: ioutil.Discard,
+			}
+
+			err = app.Run(append([]string{"test", "--config", file}, tt.args...))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if got := c.Sources(); !reflect.DeepEqual(got, tt.wantSources) {
+				t.Errorf("Sources() = %+v, want %+v", got, tt.wantSources)
+			}
+
+			if c.Output != tt.wantOutput {
+				t.Errorf("Output = %s, want %s", c.Output, tt.wantOutput)
+			}
+		})
+	}
+}
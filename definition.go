@@ -1,5 +1,60 @@
 package ecsgen
 
+const (
+	// LevelCore denotes fields that are the most common across all use cases.
+	LevelCore = "core"
+
+	// LevelExtended denotes fields that are defined by ECS for more specific use cases.
+	LevelExtended = "extended"
+
+	// LevelCustom denotes fields that are not part of ECS.
+	LevelCustom = "custom"
+)
+
+var (
+	// Levels is the list of valid values for Definition.Level.
+	Levels = []string{
+		LevelCore,
+		LevelExtended,
+		LevelCustom,
+	}
+
+	// FieldTypes is the list of valid values for Definition.Type and MultiField.Type.
+	FieldTypes = []string{
+		"keyword",
+		"text",
+		"match_only_text",
+		"wildcard",
+		"constant_keyword",
+		"version",
+		"ip",
+		"geo_point",
+		"geo_shape",
+		"long",
+		"unsigned_long",
+		"integer",
+		"short",
+		"byte",
+		"float",
+		"double",
+		"half_float",
+		"scaled_float",
+		"date",
+		"date_nanos",
+		"boolean",
+		"object",
+		"flattened",
+		"nested",
+		"histogram",
+		"alias",
+	}
+
+	// NormalizeValues is the list of valid values for Definition.Normalize.
+	NormalizeValues = []string{
+		"array",
+	}
+)
+
 // Definition represents the YAML definition in the ECS generated "ecs_flat.yml" schema definition.
 // nolint:maligned
 type Definition struct {
@@ -0,0 +1,51 @@
+package ecsgen
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmptyPath is the cause of a SchemaError when an empty path is resolved.
+	ErrEmptyPath = errors.New("path cannot be empty")
+
+	// ErrInvalidName is the cause of a SchemaError when a path contains an empty element,
+	// or a Node name contains a path separator.
+	ErrInvalidName = errors.New("invalid node name")
+
+	// ErrReadOnly is the cause of a SchemaError when a read-only tree would have to be modified.
+	ErrReadOnly = errors.New("tree is read-only")
+
+	// ErrUnknownType is the cause of a SchemaError when a Definition's type cannot be translated.
+	ErrUnknownType = errors.New("unknown field type")
+)
+
+// SchemaError describes a problem with a specific location within the schema tree. Callers
+// can use errors.As to retrieve it, and errors.Is to check the Cause against the Err* values.
+type SchemaError struct {
+	// Path is the absolute path of the Node that caused the error.
+	Path string
+
+	// Field is the name of the element within Path that caused the error, if any.
+	Field string
+
+	// Cause is the underlying error.
+	Cause error
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	switch {
+	case e.Path == "" && e.Field == "":
+		return fmt.Sprintf("schema error: %v", e.Cause)
+	case e.Field == "":
+		return fmt.Sprintf("schema error at %s: %v", e.Path, e.Cause)
+	default:
+		return fmt.Sprintf("schema error at %s (field %q): %v", e.Path, e.Field, e.Cause)
+	}
+}
+
+// Unwrap returns the underlying cause of the error.
+func (e *SchemaError) Unwrap() error {
+	return e.Cause
+}
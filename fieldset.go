@@ -0,0 +1,115 @@
+package ecsgen
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Fieldset represents a fieldset definition in the ECS generated "ecs_nested.yml" schema definition.
+// Fieldsets hold the metadata for the objects that ECS defines explicitly, such as "client" or "geo".
+// nolint:maligned
+type Fieldset struct {
+	Name        string                 `config:"name" json:"name,omitempty" yaml:"name,omitempty" mapstructure:"name,omitempty"`
+	Title       string                 `config:"title" json:"title,omitempty" yaml:"title,omitempty" mapstructure:"title,omitempty"`
+	Group       int                    `config:"group" json:"group,omitempty" yaml:"group,omitempty" mapstructure:"group,omitempty"`
+	Short       string                 `config:"short" json:"short,omitempty" yaml:"short,omitempty" mapstructure:"short,omitempty"`
+	Description string                 `config:"description" json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description,omitempty"`
+	Footnote    string                 `config:"footnote" json:"footnote,omitempty" yaml:"footnote,omitempty" mapstructure:"footnote,omitempty"`
+	Type        string                 `config:"type" json:"type,omitempty" yaml:"type,omitempty" mapstructure:"type,omitempty"`
+	Prefix      string                 `config:"prefix" json:"prefix,omitempty" yaml:"prefix,omitempty" mapstructure:"prefix,omitempty"`
+	Root        bool                   `config:"root" json:"root,omitempty" yaml:"root,omitempty" mapstructure:"root,omitempty"`
+	Reusable    *Reusable              `config:"reusable" json:"reusable,omitempty" yaml:"reusable,omitempty" mapstructure:"reusable,omitempty"`
+	Fields      map[string]*Definition `config:"fields" json:"fields,omitempty" yaml:"fields,omitempty" mapstructure:"fields,omitempty"`
+}
+
+// Reusable defines where a reusable Fieldset is expected to be nested within the ECS schema.
+type Reusable struct {
+	TopLevel bool             `config:"top_level" json:"top_level,omitempty" yaml:"top_level,omitempty" mapstructure:"top_level,omitempty"`
+	Expected []*ReuseLocation `config:"expected" json:"expected,omitempty" yaml:"expected,omitempty" mapstructure:"expected,omitempty"`
+}
+
+// ReuseLocation is a single location a reusable Fieldset is expected to be nested at. ECS
+// schemas give it either as a string (i.e. "client"), which nests the fieldset under its own
+// name, or as an object (i.e. {at: process, as: parent}), which can nest it under another name.
+type ReuseLocation struct {
+	// At is the path of the object the fieldset is nested within, i.e. "client".
+	At string `config:"at" json:"at" yaml:"at" mapstructure:"at"`
+
+	// As is the name the fieldset is nested as. If it is empty, the fieldset's own name is used.
+	As string `config:"as" json:"as,omitempty" yaml:"as,omitempty" mapstructure:"as,omitempty"`
+
+	// ShortOverride replaces the short description of the fieldset at this location.
+	ShortOverride string `config:"short_override" json:"short_override,omitempty" yaml:"short_override,omitempty" mapstructure:"short_override,omitempty"`
+}
+
+// Path returns the path the fieldset with the given name is nested at. For example, the
+// "geo" fieldset expected at "client" is nested at "client.geo", and the "process" fieldset
+// expected at {at: process, as: parent} is nested at "process.parent".
+func (l *ReuseLocation) Path(fieldset string) string {
+	if l.As == "" {
+		return l.At + "." + fieldset
+	}
+
+	return l.At + "." + l.As
+}
+
+// Unpack implements the go-ucfg Unpacker interface, so that both the string
+// and the object form of a location can be read from YAML.
+func (l *ReuseLocation) Unpack(v interface{}) error {
+	switch x := v.(type) {
+	case string:
+		*l = ReuseLocation{At: x}
+	case map[string]interface{}:
+		*l = ReuseLocation{}
+
+		for key, dest := range map[string]*string{"at": &l.At, "as": &l.As, "short_override": &l.ShortOverride} {
+			val, found := x[key]
+			if !found {
+				continue
+			}
+
+			str, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("reuse location %s must be a string, not %T", key, val)
+			}
+
+			*dest = str
+		}
+	default:
+		return fmt.Errorf("reuse location must be a string or an object, not %T", v)
+	}
+
+	if l.At == "" {
+		return fmt.Errorf("reuse location does not specify where the fieldset is nested (at)")
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Like Unpack, it accepts
+// both the string and the object form of a location.
+func (l *ReuseLocation) UnmarshalJSON(data []byte) error {
+	var v interface{}
+
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	return l.Unpack(v)
+}
+
+// IsReusable returns true if the Fieldset can be nested within other Fieldsets.
+func (f *Fieldset) IsReusable() bool {
+	return f.Reusable != nil
+}
+
+// IsTopLevel returns true if the Fieldset's fields are expected at the top level of
+// the schema. Root fieldsets (such as "base") and non-reusable fieldsets always are.
+func (f *Fieldset) IsTopLevel() bool {
+	if f.Reusable == nil {
+		return true
+	}
+
+	return f.Reusable.TopLevel
+}
@@ -1,7 +1,10 @@
 package debug
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/gen0cide/ecsgen"
@@ -9,7 +12,19 @@ import (
 	"github.com/urfave/cli"
 )
 
+var defaultFilename = "debug.txt"
+
 type debug struct {
+	Query    string
+	Filename string
+	query    *ecsgen.Query
+}
+
+// Options configures the debug output plugin when it is created in code. The
+// fields have the same meaning as the plugin's CLI flags.
+type Options struct {
+	Query    string
+	Filename string
 }
 
 // New is a constructor for an empty debug output plugin.
@@ -17,6 +32,14 @@ func New() generator.Generator {
 	return &debug{}
 }
 
+// NewWithOptions is a constructor for a debug output plugin that is configured in code.
+func NewWithOptions(opts Options) generator.Generator {
+	return &debug{
+		Query:    opts.Query,
+		Filename: opts.Filename,
+	}
+}
+
 // ID implements the generator.Generator interface.
 // Package: github.com/gen0cide/ecsgen/generator
 func (d *debug) ID() string {
@@ -26,37 +49,80 @@ func (d *debug) ID() string {
 // CLIFlags implements the generator.Generator interface.
 // Package: github.com/gen0cide/ecsgen/generator
 func (d *debug) CLIFlags() []cli.Flag {
-	return []cli.Flag{}
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "query",
+			Usage:       "Only print the nodes that match an ecsgen query (i.e. \"process.**[type=keyword]\").",
+			EnvVars:     []string{"QUERY"},
+			Destination: &d.Query,
+		},
+		&cli.StringFlag{
+			Name:        "output-filename",
+			Usage:       fmt.Sprintf("Destination filename for the tree. (default: %s)", defaultFilename),
+			EnvVars:     []string{"OUTPUT_FILENAME"},
+			Destination: &d.Filename,
+		},
+	}
 }
 
 // Validate implements the generator.Generator interface.
 // Package: github.com/gen0cide/ecsgen/generator
 func (d *debug) Validate() error {
+	// Use the default unless otherwise specified
+	if d.Filename == "" {
+		d.Filename = defaultFilename
+	}
+
+	if d.Query == "" {
+		return nil
+	}
+
+	q, err := ecsgen.ParseQuery(d.Query)
+	if err != nil {
+		return err
+	}
+
+	d.query = q
+
 	return nil
 }
 
 // Execute implements the generator.Generator interface.
 // Package: github.com/gen0cide/ecsgen/generator
-func (d *debug) Execute(r *ecsgen.Root) error {
-	walkFn := func(n *ecsgen.Node) error {
-		level := len(strings.Split(n.Path, ".")) - 1
-		indent := new(strings.Builder)
-		for i := 0; i < level; i++ {
-			indent.WriteString("\t")
-		}
-		if n.IsObject() {
-			fmt.Printf("%s[OBJECT] %s\n", indent.String(), n.Path)
-			return nil
+func (d *debug) Execute(r *ecsgen.Root, out generator.Output) error {
+	buf := new(bytes.Buffer)
+
+	// a query prints the matching nodes as a flat list
+	if d.query != nil {
+		for _, n := range d.query.Select(r) {
+			d.print(buf, n, 0)
 		}
 
-		fmt.Printf("%s(field) %s\n", indent.String(), n.Path)
-		return nil
+		return out.WriteFile(d.Filename, buf.Bytes())
+	}
+
+	walker := &ecsgen.Walker{
+		Enter: func(n *ecsgen.Node, depth int) error {
+			d.print(buf, n, depth)
+			return nil
+		},
 	}
 
-	err := ecsgen.Walk(r, walkFn)
+	err := walker.Walk(context.Background(), r)
 	if err != nil {
 		return fmt.Errorf("error walking tree: %v", err)
 	}
 
-	return nil
+	return out.WriteFile(d.Filename, buf.Bytes())
+}
+
+// print writes a single Node to w, indented to the given depth.
+func (d *debug) print(w io.Writer, n *ecsgen.Node, depth int) {
+	indent := strings.Repeat("\t", depth)
+	if n.IsObject() {
+		fmt.Fprintf(w, "%s[OBJECT] %s\n", indent, n.Path)
+		return
+	}
+
+	fmt.Fprintf(w, "%s(field) %s\n", indent, n.Path)
 }
@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gen0cide/ecsgen"
+)
+
+// Task is a generator along with the tree it is executed with.
+type Task struct {
+	Generator Generator
+	Root      *ecsgen.Root
+}
+
+// Error is the error returned by a single generator.
+type Error struct {
+	// ID is the ID of the generator that failed.
+	ID string
+
+	// Err is the error the generator returned.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("error running %s generator: %v", e.ID, e.Err)
+}
+
+// Unwrap returns the error the generator returned.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors is the list of generators that failed, in the order the generators were given.
+// It implements the error interface so that every failure can be reported at once.
+type Errors []*Error
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for idx, err := range e {
+		msgs[idx] = err.Error()
+	}
+
+	return fmt.Sprintf("%d generator(s) failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the error of every generator that failed, so that errors.Is and errors.As
+// find the errors of the generators, such as an *ecsgen.SchemaError.
+func (e Errors) Unwrap() []error {
+	ret := make([]error, len(e))
+	for idx, err := range e {
+		ret[idx] = err
+	}
+
+	return ret
+}
+
+// Execute runs every task, writing the generated files to out. The tasks are executed
+// concurrently, so their trees should be frozen (see ecsgen.Root.Freeze) and out must be
+// safe for concurrent use. Every task is run to completion, and the failures are returned
+// as Errors. If failFast is true, the tasks are instead executed one at a time, stopping
+// at the first failure. A task fails if it writes a file another task already wrote, or if
+// the generator panics.
+func Execute(tasks []Task, out Output, failFast bool) error {
+	written := &writtenFiles{owners: map[string]string{}}
+
+	if failFast {
+		for _, t := range tasks {
+			err := run(t, written.output(t.Generator.ID(), out))
+			if err != nil {
+				return Errors{{ID: t.Generator.ID(), Err: err}}
+			}
+		}
+
+		return nil
+	}
+
+	// each task records its own result, so the errors keep the order of the tasks
+	results := make([]error, len(tasks))
+
+	wg := new(sync.WaitGroup)
+
+	for idx, t := range tasks {
+		wg.Add(1)
+
+		go func(idx int, t Task) {
+			defer wg.Done()
+
+			results[idx] = run(t, written.output(t.Generator.ID(), out))
+		}(idx, t)
+	}
+
+	wg.Wait()
+
+	failed := Errors{}
+
+	for idx, err := range results {
+		if err != nil {
+			failed = append(failed, &Error{ID: tasks[idx].Generator.ID(), Err: err})
+		}
+	}
+
+	if len(failed) > 0 {
+		return failed
+	}
+
+	return nil
+}
+
+// run executes a single task, turning a panic in the generator into an error so the
+// other tasks still finish and are reported.
+func run(t Task, out Output) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("generator panicked: %v", r)
+		}
+	}()
+
+	return t.Generator.Execute(t.Root, out)
+}
+
+// writtenFiles tracks which task wrote each file, so that two tasks cannot write the same file.
+type writtenFiles struct {
+	sync.Mutex
+
+	owners map[string]string
+}
+
+// output returns an Output for the task with the given ID, which claims every file it writes.
+func (w *writtenFiles) output(id string, out Output) Output {
+	return &claimingOutput{
+		id:      id,
+		out:     out,
+		written: w,
+	}
+}
+
+// claim records that the task with the given ID wrote a file, returning an error if another
+// task already did. The name is cleaned, so "./a.go" and "a.go" are the same file.
+func (w *writtenFiles) claim(id string, name string) error {
+	w.Lock()
+	defer w.Unlock()
+
+	key := path.Clean(filepath.ToSlash(name))
+
+	if owner, found := w.owners[key]; found && owner != id {
+		return fmt.Errorf("%s was already written by the %s generator", name, owner)
+	}
+
+	w.owners[key] = id
+
+	return nil
+}
+
+// claimingOutput claims the files a single task writes before passing them to the real Output.
+type claimingOutput struct {
+	id      string
+	out     Output
+	written *writtenFiles
+}
+
+// WriteFile implements the generator.Output interface.
+func (c *claimingOutput) WriteFile(name string, contents []byte) error {
+	err := c.written.claim(c.id, name)
+	if err != nil {
+		return err
+	}
+
+	return c.out.WriteFile(name, contents)
+}
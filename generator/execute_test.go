@@ -0,0 +1,212 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/urfave/cli"
+)
+
+// testGenerator writes a fixed list of files, then fails or panics if asked to.
+type testGenerator struct {
+	id    string
+	files []string
+	err   error
+	panic bool
+	ran   *[]string
+}
+
+func (g *testGenerator) ID() string           { return g.id }
+func (g *testGenerator) CLIFlags() []cli.Flag { return nil }
+func (g *testGenerator) Validate() error      { return nil }
+
+func (g *testGenerator) Execute(_ *ecsgen.Root, out Output) error {
+	if g.ran != nil {
+		*g.ran = append(*g.ran, g.id)
+	}
+
+	for _, name := range g.files {
+		err := out.WriteFile(name, []byte(g.id))
+		if err != nil {
+			return err
+		}
+	}
+
+	if g.panic {
+		panic("boom")
+	}
+
+	return g.err
+}
+
+func TestExecute(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name       string
+		generators []*testGenerator
+		failFast   bool
+		wantFailed []string
+		wantErrs   []string
+		wantFiles  []string
+	}{
+		{
+			name: "every task succeeds",
+			generators: []*testGenerator{
+				{id: "a", files: []string{"a.go"}},
+				{id: "b", files: []string{"b.go", "sub/b.go"}},
+			},
+			wantFiles: []string{"a.go", "b.go", "sub/b.go"},
+		},
+		{
+			name: "every failure is reported in task order",
+			generators: []*testGenerator{
+				{id: "a", err: errFailed},
+				{id: "b", files: []string{"b.go"}},
+				{id: "c", err: errFailed},
+			},
+			wantFailed: []string{"a", "c"},
+			wantErrs:   []string{"failed", "failed"},
+			wantFiles:  []string{"b.go"},
+		},
+		{
+			name: "fail fast stops at the first failure",
+			generators: []*testGenerator{
+				{id: "a", files: []string{"a.go"}},
+				{id: "b", err: errFailed},
+				{id: "c", files: []string{"c.go"}},
+			},
+			failFast:   true,
+			wantFailed: []string{"b"},
+			wantErrs:   []string{"failed"},
+			wantFiles:  []string{"a.go"},
+		},
+		{
+			name: "panics are reported as errors",
+			generators: []*testGenerator{
+				{id: "a", panic: true},
+				{id: "b", files: []string{"b.go"}},
+			},
+			wantFailed: []string{"a"},
+			wantErrs:   []string{"generator panicked: boom"},
+			wantFiles:  []string{"b.go"},
+		},
+		{
+			name: "panics are reported as errors when failing fast",
+			generators: []*testGenerator{
+				{id: "a", panic: true},
+			},
+			failFast:   true,
+			wantFailed: []string{"a"},
+			wantErrs:   []string{"generator panicked: boom"},
+			wantFiles:  []string{},
+		},
+		{
+			name: "two tasks cannot write the same file",
+			generators: []*testGenerator{
+				{id: "a", files: []string{"out.go"}},
+				{id: "b", files: []string{"./out.go"}},
+			},
+			failFast:   true,
+			wantFailed: []string{"b"},
+			wantErrs:   []string{"./out.go was already written by the a generator"},
+			wantFiles:  []string{"out.go"},
+		},
+		{
+			name: "a task can write the same file twice",
+			generators: []*testGenerator{
+				{id: "a", files: []string{"out.go", "out.go"}},
+			},
+			wantFiles: []string{"out.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := []Task{}
+			for _, g := range tt.generators {
+				tasks = append(tasks, Task{Generator: g, Root: ecsgen.NewRoot()})
+			}
+
+			out := NewMemoryOutput()
+
+			err := Execute(tasks, out, tt.failFast)
+
+			if len(tt.wantFailed) == 0 {
+				if err != nil {
+					t.Fatalf("Execute() error = %v", err)
+				}
+			} else {
+				var failed Errors
+				if !errors.As(err, &failed) {
+					t.Fatalf("Execute() error = %v, want Errors", err)
+				}
+
+				ids, msgs := []string{}, []string{}
+				for _, e := range failed {
+					ids = append(ids, e.ID)
+					msgs = append(msgs, e.Err.Error())
+				}
+
+				if !reflect.DeepEqual(ids, tt.wantFailed) || !reflect.DeepEqual(msgs, tt.wantErrs) {
+					t.Errorf("Execute() failed %v %q, want %v %q", ids, msgs, tt.wantFailed, tt.wantErrs)
+				}
+
+				if !strings.HasPrefix(err.Error(), fmt.Sprintf("%d generator(s) failed", len(tt.wantFailed))) {
+					t.Errorf("Execute() error = %v", err)
+				}
+			}
+
+			if got := out.Names(); !reflect.DeepEqual(got, tt.wantFiles) {
+				t.Errorf("Execute() wrote %v, want %v", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestExecuteFailFastOrder(t *testing.T) {
+	ran := []string{}
+
+	tasks := []Task{}
+	for _, id := range []string{"a", "b", "c"} {
+		tasks = append(tasks, Task{Generator: &testGenerator{id: id, ran: &ran}, Root: ecsgen.NewRoot()})
+	}
+
+	err := Execute(tasks, NewMemoryOutput(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Execute() ran %v, want %v", ran, want)
+	}
+}
+
+func TestErrorsUnwrap(t *testing.T) {
+	schemaErr := &ecsgen.SchemaError{Path: "event.duration", Field: "duration", Cause: ecsgen.ErrUnknownType}
+
+	tasks := []Task{
+		{Generator: &testGenerator{id: "a", err: errors.New("failed")}, Root: ecsgen.NewRoot()},
+		{Generator: &testGenerator{id: "b", err: fmt.Errorf("wrapped: %w", schemaErr)}, Root: ecsgen.NewRoot()},
+	}
+
+	err := Execute(tasks, NewMemoryOutput(), false)
+
+	var got *ecsgen.SchemaError
+	if !errors.As(err, &got) || got != schemaErr {
+		t.Errorf("errors.As() did not find the *ecsgen.SchemaError in %v", err)
+	}
+
+	if !errors.Is(err, ecsgen.ErrUnknownType) {
+		t.Errorf("errors.Is() did not find ecsgen.ErrUnknownType in %v", err)
+	}
+
+	var failed *Error
+	if !errors.As(err, &failed) || failed.ID != "a" {
+		t.Errorf("errors.As() = %v, want the first failed generator", failed)
+	}
+}
@@ -0,0 +1,302 @@
+// Package external runs output plugins that are separate executables, in the style of protoc
+// plugins. Any executable on the PATH named "ecsgen-gen-<id>" is an output plugin with that ID,
+// and can be written in any language. Plugins are only looked for when asked to, with Discover
+// or Lookup; the ecsgen CLI registers every plugin Discover finds.
+//
+// The plugin is run once per execution. It receives a Request as JSON on stdin, and must write a
+// Response as JSON to stdout before exiting. Anything written to stderr is passed through, and a
+// non-zero exit status fails the plugin.
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/generator"
+	"github.com/urfave/cli"
+)
+
+const (
+	// ExecutablePrefix is the prefix of the name of every external plugin executable.
+	ExecutablePrefix = "ecsgen-gen-"
+
+	// ProtocolVersion is the version of the Request and Response format. It is
+	// incremented whenever a change would break existing plugins.
+	ProtocolVersion = 1
+)
+
+// Request is written to the stdin of an external plugin.
+type Request struct {
+	// ProtocolVersion is the version of the format, see ProtocolVersion.
+	ProtocolVersion int `json:"protocol_version"`
+
+	// Generator is the ID of the plugin.
+	Generator string `json:"generator"`
+
+	// Options are the key=value parameters the plugin was given, i.e. with --opt-<id>-param.
+	Options map[string]string `json:"options"`
+
+	// Root is the schema tree the plugin is executed with. See ecsgen.Root.MarshalJSON.
+	Root *ecsgen.Root `json:"root"`
+}
+
+// Response is read from the stdout of an external plugin.
+type Response struct {
+	// Files are written to the output, in order.
+	Files []*File `json:"files"`
+
+	// Error fails the plugin when it is not empty. Any files are ignored.
+	Error string `json:"error,omitempty"`
+}
+
+// File is a single file generated by an external plugin.
+type File struct {
+	// Name is the path of the file, relative to the output. It must be a clean, slash
+	// separated relative path (see path.Clean) that does not contain "..".
+	Name string `json:"name"`
+
+	// Content is the contents of the file.
+	Content string `json:"content"`
+}
+
+type plugin struct {
+	id      string
+	path    string
+	params  *cli.StringSlice
+	options map[string]string
+}
+
+// Options configures an external plugin that is created in code.
+type Options struct {
+	// Params are passed to the plugin as its options, the same as the param flag.
+	Params map[string]string
+}
+
+// New returns a constructor for the external plugin with the given ID, which runs the executable at path.
+func New(id string, path string) generator.Factory {
+	return func() generator.Generator {
+		return &plugin{
+			id:     id,
+			path:   path,
+			params: cli.NewStringSlice(),
+		}
+	}
+}
+
+// NewWithOptions is a constructor for an external plugin that is configured in code, which
+// runs the executable at path.
+func NewWithOptions(id string, path string, opts Options) generator.Generator {
+	// sort the params so they are passed in a stable order
+	params := []string{}
+	for k, v := range opts.Params {
+		params = append(params, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	sort.Strings(params)
+
+	return &plugin{
+		id:     id,
+		path:   path,
+		params: cli.NewStringSlice(params...),
+	}
+}
+
+// Discover finds the external plugins in the directories of the PATH environment variable. If
+// more than one executable has the same name, the first one found is used, the same way the
+// shell resolves commands. The constructors are sorted by plugin ID.
+func Discover() []generator.Factory {
+	found := discover()
+
+	ids := []string{}
+	for id := range found {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	ret := []generator.Factory{}
+	for _, id := range ids {
+		ret = append(ret, New(id, found[id]))
+	}
+
+	return ret
+}
+
+// Lookup returns the path of the executable of the external plugin with the given ID, using
+// the same rules as Discover.
+func Lookup(id string) (string, error) {
+	path, found := discover()[id]
+	if !found {
+		return "", fmt.Errorf("external plugin %s was not found: no %s%s executable on the PATH", id, ExecutablePrefix, id)
+	}
+
+	return path, nil
+}
+
+// discover returns the path of every external plugin on the PATH, keyed by plugin ID.
+func discover() map[string]string {
+	found := map[string]string{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			id, ok := pluginID(file)
+			if !ok {
+				continue
+			}
+
+			if _, seen := found[id]; !seen {
+				found[id] = filepath.Join(dir, file.Name())
+			}
+		}
+	}
+
+	return found
+}
+
+// pluginID returns the plugin ID for an executable, and false if it is not an external plugin.
+func pluginID(file os.FileInfo) (string, bool) {
+	name := file.Name()
+	if file.IsDir() || !strings.HasPrefix(name, ExecutablePrefix) {
+		return "", false
+	}
+
+	// executables are marked by their extension on windows, and by their mode everywhere else
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	} else if file.Mode()&0111 == 0 {
+		return "", false
+	}
+
+	id := strings.TrimPrefix(name, ExecutablePrefix)
+
+	// the ID has to be usable as a plugin ID
+	if id == "" || strings.ContainsAny(id, generator.InstanceSeparator+".") {
+		return "", false
+	}
+
+	return id, true
+}
+
+// validateName checks that the name of a file returned by a plugin is a relative, clean, slash
+// separated path that stays within the output, the same rules protoc applies to its plugins.
+func validateName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("file without a name")
+	case path.IsAbs(name) || filepath.IsAbs(name) || strings.Contains(name, "\\"):
+		return fmt.Errorf("file name %q must be a relative, slash separated path", name)
+	case path.Clean(name) != name:
+		return fmt.Errorf("file name %q is not a clean path (%q)", name, path.Clean(name))
+	case name == ".." || strings.HasPrefix(name, "../"):
+		return fmt.Errorf("file name %q cannot contain \"..\"", name)
+	}
+
+	return nil
+}
+
+// ID implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (p *plugin) ID() string {
+	return p.id
+}
+
+// CLIFlags implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (p *plugin) CLIFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        "param",
+			Usage:       fmt.Sprintf("Parameter passed to the external plugin %s, in the form key=value. (Can be used multiple times).", p.path),
+			EnvVars:     []string{"PARAM"},
+			Value:       p.params,
+			Destination: p.params,
+		},
+	}
+}
+
+// Validate implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (p *plugin) Validate() error {
+	p.options = map[string]string{}
+
+	for _, param := range p.params.Value() {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("parameter %q must be in the form key=value", param)
+		}
+
+		p.options[parts[0]] = parts[1]
+	}
+
+	return nil
+}
+
+// Execute implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (p *plugin) Execute(r *ecsgen.Root, out generator.Output) error {
+	input, err := json.Marshal(&Request{
+		ProtocolVersion: ProtocolVersion,
+		Generator:       p.id,
+		Options:         p.options,
+		Root:            r,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding request: %v", err)
+	}
+
+	stdout := new(bytes.Buffer)
+
+	cmd := exec.Command(p.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error running %s: %v", p.path, err)
+	}
+
+	resp := &Response{}
+
+	err = json.Unmarshal(stdout.Bytes(), resp)
+	if err != nil {
+		return fmt.Errorf("invalid response from %s: %v", p.path, err)
+	}
+
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+
+	// check every name before anything is written, so a bad response writes nothing
+	for _, file := range resp.Files {
+		err = validateName(file.Name)
+		if err != nil {
+			return fmt.Errorf("invalid response from %s: %v", p.path, err)
+		}
+	}
+
+	for _, file := range resp.Files {
+		err = out.WriteFile(file.Name, []byte(file.Content))
+		if err != nil {
+			return fmt.Errorf("error writing %s: %v", file.Name, err)
+		}
+	}
+
+	return nil
+}
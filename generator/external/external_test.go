@@ -0,0 +1,191 @@
+package external
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/generator"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "ecs.go"},
+		{name: "sub/dir/ecs.go"},
+		{name: "..ecs.go"},
+		{name: "", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "../ecs.go", wantErr: true},
+		{name: "/etc/ecs.go", wantErr: true},
+		{name: "sub\\ecs.go", wantErr: true},
+		{name: "C:\\ecs.go", wantErr: true},
+		{name: "./ecs.go", wantErr: true},
+		{name: "sub//ecs.go", wantErr: true},
+		{name: "sub/", wantErr: true},
+		{name: "sub/../../ecs.go", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateName(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fileInfo is an os.FileInfo with a name and mode.
+type fileInfo struct {
+	name string
+	mode os.FileMode
+}
+
+func (f fileInfo) Name() string       { return f.name }
+func (f fileInfo) Size() int64        { return 0 }
+func (f fileInfo) Mode() os.FileMode  { return f.mode }
+func (f fileInfo) ModTime() time.Time { return time.Time{} }
+func (f fileInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fileInfo) Sys() interface{}   { return nil }
+
+func TestPluginID(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   fileInfo
+		wantID string
+		wantOK bool
+	}{
+		{name: "executable", file: fileInfo{name: "ecsgen-gen-names", mode: 0755}, wantID: "names", wantOK: true},
+		{name: "executable by others", file: fileInfo{name: "ecsgen-gen-names", mode: 0601}, wantID: "names", wantOK: true},
+		{name: "not executable", file: fileInfo{name: "ecsgen-gen-names", mode: 0644}},
+		{name: "directory", file: fileInfo{name: "ecsgen-gen-names", mode: os.ModeDir | 0755}},
+		{name: "other executable", file: fileInfo{name: "ecsgen", mode: 0755}},
+		{name: "empty id", file: fileInfo{name: "ecsgen-gen-", mode: 0755}},
+		{name: "instance separator", file: fileInfo{name: "ecsgen-gen-names" + generator.InstanceSeparator + "api", mode: 0755}},
+		{name: "extension", file: fileInfo{name: "ecsgen-gen-names.sh", mode: 0755}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := pluginID(tt.file)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("pluginID(%s) = %q, %v, want %q, %v", tt.file.name, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		status    string
+		wantErr   string
+		wantFiles map[string][]byte
+	}{
+		{
+			name:     "files are written in order",
+			response: `{"files": [{"name": "ecs.txt", "content": "client"}, {"name": "sub/ecs.txt", "content": ""}]}`,
+			wantFiles: map[string][]byte{
+				"ecs.txt":     []byte("client"),
+				"sub/ecs.txt": []byte(""),
+			},
+		},
+		{
+			name:      "response error",
+			response:  `{"files": [{"name": "ecs.txt", "content": "client"}], "error": "asked to fail"}`,
+			wantErr:   "asked to fail",
+			wantFiles: map[string][]byte{},
+		},
+		{
+			name:      "invalid response",
+			response:  `{"files": [`,
+			wantErr:   "invalid response from",
+			wantFiles: map[string][]byte{},
+		},
+		{
+			name:      "unsafe file names write nothing",
+			response:  `{"files": [{"name": "ecs.txt", "content": "client"}, {"name": "../ecs.txt", "content": "client"}]}`,
+			wantErr:   `file name "../ecs.txt" cannot contain ".."`,
+			wantFiles: map[string][]byte{},
+		},
+		{
+			name:      "non-zero exit status",
+			response:  `{"files": []}`,
+			status:    "3",
+			wantErr:   "exit status 3",
+			wantFiles: map[string][]byte{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestFile := filepath.Join(t.TempDir(), "request.json")
+
+			t.Setenv("ECSGEN_TEST_REQUEST", requestFile)
+			t.Setenv("ECSGEN_TEST_RESPONSE", tt.response)
+			t.Setenv("ECSGEN_TEST_STATUS", tt.status)
+
+			g := NewWithOptions("test", filepath.Join("testdata", "ecsgen-gen-test"), Options{Params: map[string]string{"key": "value"}})
+
+			err := g.Validate()
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			root := ecsgen.NewRoot()
+
+			node, err := root.Branch("client.address")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			node.Definition = &ecsgen.Definition{FlatName: "client.address", Type: "keyword"}
+
+			out := generator.NewMemoryOutput()
+
+			err = g.Execute(root, out)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if got := out.Files(); !reflect.DeepEqual(got, tt.wantFiles) {
+				t.Errorf("Execute() wrote %q, want %q", got, tt.wantFiles)
+			}
+
+			// the plugin is always given the whole request
+			data, err := ioutil.ReadFile(requestFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &Request{}
+
+			err = json.Unmarshal(data, req)
+			if err != nil {
+				t.Fatalf("invalid request %s: %v", data, err)
+			}
+
+			if req.ProtocolVersion != ProtocolVersion || req.Generator != "test" || !reflect.DeepEqual(req.Options, map[string]string{"key": "value"}) {
+				t.Errorf("request = %s", data)
+			}
+
+			if _, found := req.Root.Lookup("client.address"); !found {
+				t.Errorf("request root does not have client.address: %s", data)
+			}
+		})
+	}
+}
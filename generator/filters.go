@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"github.com/urfave/cli"
+)
+
+const (
+	// OptionInclude is the name of the option every generator has for the rules that
+	// select the fields it is executed with, i.e. --opt-gostruct-include.
+	OptionInclude = "include"
+
+	// OptionExclude is the name of the option every generator has for the rules that
+	// remove fields from the ones it is executed with, i.e. --opt-gostruct-exclude.
+	OptionExclude = "exclude"
+)
+
+// Filters holds the include and exclude rules of a generator. The rules use the same
+// syntax as the whitelist and blacklist, and are applied on top of them.
+type Filters struct {
+	Include []string
+	Exclude []string
+}
+
+// IsEmpty returns true if there are no rules, meaning the generator uses the whole schema.
+func (f Filters) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// FiltersOf returns the include and exclude rules a generator was configured with. Only the
+// generators returned by a Registry, NewInstance or WithFilters have rules.
+func FiltersOf(g Generator) Filters {
+	inst, ok := g.(*instance)
+	if !ok {
+		return Filters{}
+	}
+
+	return Filters{
+		Include: inst.filters.include.Value(),
+		Exclude: inst.filters.exclude.Value(),
+	}
+}
+
+// WithFilters returns a copy of a generator that is executed with only the fields that pass
+// the given rules. It is used to configure filters in code instead of with CLI flags.
+func WithFilters(g Generator, f Filters) Generator {
+	// replace the rules of generators that already have them
+	name := ""
+	if inst, ok := g.(*instance); ok {
+		g, name = inst.Generator, inst.name
+	}
+
+	return &instance{
+		Generator: g,
+		name:      name,
+		filters: &filterOptions{
+			include: cli.NewStringSlice(f.Include...),
+			exclude: cli.NewStringSlice(f.Exclude...),
+		},
+	}
+}
+
+// filterOptions binds the include and exclude options of a single generator.
+type filterOptions struct {
+	include *cli.StringSlice
+	exclude *cli.StringSlice
+}
+
+// newFilterOptions creates an empty set of filter options.
+func newFilterOptions() *filterOptions {
+	return &filterOptions{
+		include: cli.NewStringSlice(),
+		exclude: cli.NewStringSlice(),
+	}
+}
+
+// flags returns the CLI flags for the filter options. They are shimmed along with
+// the generator's own flags.
+func (f *filterOptions) flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        OptionInclude,
+			Usage:       "Only generate the fields that match a regular expression, glob or query (see --whitelist). (Can be used multiple times).",
+			EnvVars:     []string{"INCLUDE"},
+			Value:       f.include,
+			Destination: f.include,
+		},
+		&cli.StringSliceFlag{
+			Name:        OptionExclude,
+			Usage:       "Do not generate the fields that match a regular expression, glob or query (see --blacklist). (Can be used multiple times).",
+			EnvVars:     []string{"EXCLUDE"},
+			Value:       f.exclude,
+			Destination: f.exclude,
+		},
+	}
+}
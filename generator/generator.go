@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/gen0cide/ecsgen"
@@ -29,56 +30,106 @@ type Generator interface {
 	Validate() error
 
 	// Execute will be called by the application when the loader has
-	// successfully loaded the package.
-	Execute(r *ecsgen.Root) error
+	// successfully loaded the package. Generated files must be written
+	// to out, which decides where they end up.
+	Execute(r *ecsgen.Root, out Output) error
 }
 
+// Factory is a constructor for an empty Generator. Each call must return a new
+// instance, so that a Generator can be configured more than once.
+type Factory func() Generator
+
 // shimCLIFlags is used to shim each generators CLI flags to prefix them with the correct
-// flag names, as well as environment variable prefixes.
-func shimCLIFlags(g Generator) []cli.Flag {
-	originalFlags := g.CLIFlags()
-	if len(originalFlags) == 0 {
-		return []cli.Flag{}
-	}
+// flag names, as well as environment variable prefixes. The flags are added to the CLI whether
+// or not the generator is enabled, so they are never required by the CLI itself. Instead,
+// RequiredOptions is checked for the generators that are enabled.
+func shimCLIFlags(g Generator) ([]cli.Flag, error) {
+	return shimFlags(ecsgen.NewIdentifier(g.ID()), g.CLIFlags(), false)
+}
 
+// shimFlags prefixes the names, aliases and environment variables of a set of flags with pluginID.
+func shimFlags(pluginID ecsgen.Identifier, originalFlags []cli.Flag, keepRequired bool) ([]cli.Flag, error) {
 	newFlags := []cli.Flag{}
-	pluginID := ecsgen.NewIdentifier(g.ID())
 
 	for _, flag := range originalFlags {
-		var newFlag cli.Flag
-
-		// figure out the type of the flag and shim it
-		switch tflag := flag.(type) {
-		case *cli.BoolFlag:
-			newFlag = &cli.BoolFlag{
-				Name:        prefixName(pluginID, tflag.Name),
-				Usage:       tflag.Usage,
-				EnvVars:     prefixEnvVars(pluginID, tflag.EnvVars),
-				Destination: tflag.Destination,
-			}
-		case *cli.StringFlag:
-			newFlag = &cli.StringFlag{
-				Name:        prefixName(pluginID, tflag.Name),
-				Usage:       tflag.Usage,
-				EnvVars:     prefixEnvVars(pluginID, tflag.EnvVars),
-				Destination: tflag.Destination,
-				Value:       tflag.Value,
-			}
-		case *cli.StringSliceFlag:
-			newFlag = &cli.StringSliceFlag{
-				Name:        prefixName(pluginID, tflag.Name),
-				Usage:       tflag.Usage,
-				EnvVars:     prefixEnvVars(pluginID, tflag.EnvVars),
-				Destination: tflag.Destination,
-				Value:       tflag.Value,
-			}
-		default:
-			panic(fmt.Errorf("output generator does not yet implement flags of type %T", tflag))
+		shimmed, err := shimFlag(pluginID, flag, keepRequired)
+		if err != nil {
+			return nil, err
+		}
+
+		newFlags = append(newFlags, shimmed)
+	}
+
+	return newFlags, nil
+}
+
+// shimFlag copies a single flag with its names, aliases and environment variables prefixed.
+// Every other setting, such as the default value, Destination and Hidden, is kept. Required
+// is only kept if keepRequired is true, as a flag that is on the CLI for a generator that is
+// not enabled must not be required (see shimCLIFlags). All of the urfave/cli flag types share
+// the same field names, so the copy is made with reflection. Flags that are not pointers to
+// a struct with a Name are not supported.
+func shimFlag(pluginID ecsgen.Identifier, flag cli.Flag, keepRequired bool) (cli.Flag, error) {
+	original := reflect.ValueOf(flag)
+	if original.Kind() != reflect.Ptr || original.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("output generator does not yet implement flags of type %T", flag)
+	}
+
+	copied := reflect.New(original.Elem().Type())
+	copied.Elem().Set(original.Elem())
+
+	fields := copied.Elem()
+
+	name := fields.FieldByName("Name")
+	if !name.IsValid() || name.Kind() != reflect.String {
+		return nil, fmt.Errorf("output generator does not yet implement flags of type %T", flag)
+	}
+
+	name.SetString(prefixName(pluginID, name.String()))
+
+	if aliases := stringsField(fields, "Aliases"); aliases.IsValid() {
+		aliases.Set(reflect.ValueOf(prefixNames(pluginID, aliases.Interface().([]string))))
+	}
+
+	if envVars := stringsField(fields, "EnvVars"); envVars.IsValid() {
+		envVars.Set(reflect.ValueOf(prefixEnvVars(pluginID, envVars.Interface().([]string))))
+	}
+
+	if required := fields.FieldByName("Required"); required.IsValid() && required.Kind() == reflect.Bool && !keepRequired {
+		required.SetBool(false)
+	}
+
+	shimmed, ok := copied.Interface().(cli.Flag)
+	if !ok {
+		return nil, fmt.Errorf("output generator does not yet implement flags of type %T", flag)
+	}
+
+	return shimmed, nil
+}
+
+// stringsField returns the []string field of a flag with the given name, or the zero
+// reflect.Value if the flag does not have one.
+func stringsField(fields reflect.Value, name string) reflect.Value {
+	field := fields.FieldByName(name)
+	if !field.IsValid() || field.Type() != reflect.TypeOf([]string{}) {
+		return reflect.Value{}
+	}
+
+	return field
+}
+
+// RequiredOptions returns the names of the options a generator must be given, which are
+// its CLI flags that are marked as Required. They are only enforced for enabled generators.
+func RequiredOptions(g Generator) []string {
+	ret := []string{}
+
+	for _, flag := range g.CLIFlags() {
+		if f, ok := flag.(cli.RequiredFlag); ok && f.IsRequired() {
+			ret = append(ret, flag.Names()[0])
 		}
-		newFlags = append(newFlags, newFlag)
 	}
 
-	return newFlags
+	return ret
 }
 
 func prefixEnvVars(id ecsgen.Identifier, vars []string) []string {
@@ -99,3 +150,24 @@ func prefixEnvVars(id ecsgen.Identifier, vars []string) []string {
 func prefixName(id ecsgen.Identifier, name string) string {
 	return strings.Join([]string{"opt", id.Command(), name}, "-")
 }
+
+func prefixNames(id ecsgen.Identifier, names []string) []string {
+	// short circuit if names is 0 length
+	if len(names) == 0 {
+		return names
+	}
+
+	newnames := make([]string, len(names))
+
+	for idx, val := range names {
+		newnames[idx] = prefixName(id, val)
+	}
+
+	return newnames
+}
+
+// OptionFlagName returns the name of the CLI flag for a generator's option, as it is
+// exposed after shimming. For example, ("gostruct", "package-name") => "opt-gostruct-package-name".
+func OptionFlagName(generatorID string, name string) string {
+	return prefixName(ecsgen.NewIdentifier(generatorID), name)
+}
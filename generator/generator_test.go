@@ -0,0 +1,323 @@
+package generator
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/urfave/cli"
+)
+
+// valueFlag is a flag that is not a pointer to a struct, which cannot be shimmed.
+type valueFlag struct{}
+
+func (valueFlag) String() string            { return "value" }
+func (valueFlag) Apply(*flag.FlagSet) error { return nil }
+func (valueFlag) Names() []string           { return []string{"value"} }
+func (valueFlag) IsSet() bool               { return false }
+
+// listValue is a cli.Generic that holds a comma separated list.
+type listValue struct {
+	values []string
+}
+
+func (v *listValue) Set(s string) error {
+	if s == "" {
+		return errors.New("list cannot be empty")
+	}
+
+	v.values = strings.Split(s, ",")
+	return nil
+}
+
+func (v *listValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return strings.Join(v.values, ",")
+}
+
+// flagGenerator is a generator with a fixed set of CLI flags.
+type flagGenerator struct {
+	testGenerator
+
+	flags []cli.Flag
+}
+
+func (g *flagGenerator) CLIFlags() []cli.Flag { return g.flags }
+
+func TestShimFlag(t *testing.T) {
+	dest := ""
+
+	tests := []struct {
+		name         string
+		flag         cli.Flag
+		keepRequired bool
+		wantErr      bool
+		wantNames    []string
+		wantEnvVars  []string
+		wantRequired bool
+	}{
+		{
+			name:        "names, aliases and environment variables are prefixed",
+			flag:        &cli.StringFlag{Name: "package-name", Aliases: []string{"p"}, EnvVars: []string{"PACKAGE_NAME"}, Destination: &dest},
+			wantNames:   []string{"opt-basic-package-name", "opt-basic-p"},
+			wantEnvVars: []string{"ECSGEN_OPT_BASIC_PACKAGE_NAME"},
+		},
+		{
+			name:      "required is dropped",
+			flag:      &cli.StringFlag{Name: "package-name", Required: true},
+			wantNames: []string{"opt-basic-package-name"},
+		},
+		{
+			name:         "required is kept",
+			flag:         &cli.StringFlag{Name: "package-name", Required: true},
+			keepRequired: true,
+			wantNames:    []string{"opt-basic-package-name"},
+			wantRequired: true,
+		},
+		{
+			name:    "unsupported flag type",
+			flag:    valueFlag{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shimFlag(ecsgen.NewIdentifier("basic"), tt.flag, tt.keepRequired)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("shimFlag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got.Names(), tt.wantNames) {
+				t.Errorf("Names() = %v, want %v", got.Names(), tt.wantNames)
+			}
+
+			shimmed := got.(*cli.StringFlag)
+			if len(tt.wantEnvVars) > 0 && !reflect.DeepEqual(shimmed.EnvVars, tt.wantEnvVars) {
+				t.Errorf("EnvVars = %v, want %v", shimmed.EnvVars, tt.wantEnvVars)
+			}
+
+			if shimmed.Required != tt.wantRequired {
+				t.Errorf("Required = %v, want %v", shimmed.Required, tt.wantRequired)
+			}
+
+			// the original flag is not changed, and shares its Destination with the copy
+			original := tt.flag.(*cli.StringFlag)
+			if original.Name != "package-name" || shimmed.Destination != original.Destination {
+				t.Errorf("shimFlag() changed the original flag or its Destination")
+			}
+		})
+	}
+}
+
+func TestRegistryRegisterFlags(t *testing.T) {
+	r := NewRegistry()
+
+	err := r.Register(func() Generator {
+		return &flagGenerator{testGenerator: testGenerator{id: "broken"}, flags: []cli.Flag{valueFlag{}}}
+	})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Register() of a generator with an unsupported flag error = %v", err)
+	}
+
+	if _, err := r.Get("broken"); err == nil {
+		t.Error("generator with an unsupported flag was registered")
+	}
+
+	err = r.Register(func() Generator {
+		return &flagGenerator{testGenerator: testGenerator{id: "basic"}, flags: []cli.Flag{&cli.StringFlag{Name: "package-name"}}}
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	names := []string{}
+	for _, f := range r.CLIFlags() {
+		names = append(names, f.Names()[0])
+	}
+
+	// the default instance also has the filter options
+	if len(names) == 0 || names[0] != "opt-basic-package-name" {
+		t.Errorf("CLIFlags() = %v", names)
+	}
+}
+
+func TestShimFlagTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    func() (cli.Flag, func() interface{})
+		args    []string
+		env     string
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "int default",
+			flag: intFlag,
+			want: 4,
+		},
+		{
+			name: "int argument",
+			flag: intFlag,
+			args: []string{"--opt-basic-workers", "8"},
+			want: 8,
+		},
+		{
+			name: "int environment variable",
+			flag: intFlag,
+			env:  "16",
+			want: 16,
+		},
+		{
+			name:    "int invalid argument",
+			flag:    intFlag,
+			args:    []string{"--opt-basic-workers", "many"},
+			wantErr: true,
+		},
+		{
+			name:    "int invalid environment variable",
+			flag:    intFlag,
+			env:     "many",
+			wantErr: true,
+		},
+		{
+			name: "float64 default",
+			flag: float64Flag,
+			want: 0.5,
+		},
+		{
+			name: "float64 argument",
+			flag: float64Flag,
+			args: []string{"--opt-basic-ratio", "0.25"},
+			want: 0.25,
+		},
+		{
+			name: "float64 environment variable",
+			flag: float64Flag,
+			env:  "1.5",
+			want: 1.5,
+		},
+		{
+			name:    "float64 invalid argument",
+			flag:    float64Flag,
+			args:    []string{"--opt-basic-ratio", "half"},
+			wantErr: true,
+		},
+		{
+			name: "duration default",
+			flag: durationFlag,
+			want: time.Second,
+		},
+		{
+			name: "duration argument",
+			flag: durationFlag,
+			args: []string{"--opt-basic-timeout", "1m30s"},
+			want: 90 * time.Second,
+		},
+		{
+			name: "duration environment variable",
+			flag: durationFlag,
+			env:  "250ms",
+			want: 250 * time.Millisecond,
+		},
+		{
+			name:    "duration invalid argument",
+			flag:    durationFlag,
+			args:    []string{"--opt-basic-timeout", "soon"},
+			wantErr: true,
+		},
+		{
+			name: "generic default",
+			flag: genericFlag,
+			want: "a",
+		},
+		{
+			name: "generic argument",
+			flag: genericFlag,
+			args: []string{"--opt-basic-columns", "b,c"},
+			want: "b,c",
+		},
+		{
+			name: "generic environment variable",
+			flag: genericFlag,
+			env:  "d",
+			want: "d",
+		},
+		{
+			name:    "generic invalid argument",
+			flag:    genericFlag,
+			args:    []string{"--opt-basic-columns", ""},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, value := tt.flag()
+
+			shimmed, err := shimFlag(ecsgen.NewIdentifier("basic"), original, false)
+			if err != nil {
+				t.Fatalf("shimFlag() error = %v", err)
+			}
+
+			if tt.env != "" {
+				t.Setenv("ECSGEN_OPT_BASIC_VALUE", tt.env)
+			}
+
+			app := &cli.App{
+				Name:      "test",
+				Flags:     []cli.Flag{shimmed},
+				Action:    func(*cli.Context) error { return nil },
+				Writer:    ioutil.Discard,
+				ErrWriter: ioutil.Discard,
+			}
+
+			err = app.Run(append([]string{"test"}, tt.args...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := value(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// intFlag, float64Flag, durationFlag and genericFlag return a flag with a default value and the
+// VALUE environment variable, along with a function that returns the value it was set to.
+func intFlag() (cli.Flag, func() interface{}) {
+	dest := 0
+	return &cli.IntFlag{Name: "workers", Value: 4, EnvVars: []string{"VALUE"}, Destination: &dest}, func() interface{} { return dest }
+}
+
+func float64Flag() (cli.Flag, func() interface{}) {
+	dest := 0.0
+	return &cli.Float64Flag{Name: "ratio", Value: 0.5, EnvVars: []string{"VALUE"}, Destination: &dest}, func() interface{} { return dest }
+}
+
+func durationFlag() (cli.Flag, func() interface{}) {
+	dest := time.Duration(0)
+	return &cli.DurationFlag{Name: "timeout", Value: time.Second, EnvVars: []string{"VALUE"}, Destination: &dest}, func() interface{} { return dest }
+}
+
+func genericFlag() (cli.Flag, func() interface{}) {
+	dest := &listValue{values: []string{"a"}}
+	return &cli.GenericFlag{Name: "columns", Value: dest, EnvVars: []string{"VALUE"}}, func() interface{} { return dest.String() }
+}
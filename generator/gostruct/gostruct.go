@@ -7,8 +7,6 @@ import (
 	"go/format"
 	"go/parser"
 	"go/token"
-	"io/ioutil"
-	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -24,8 +22,8 @@ var (
 	// ErrInvalidPackageName is thrown when a Go package name is either not specified or is not valid.
 	ErrInvalidPackageName = errors.New("package name was either empty or an invalid go package identifier")
 
-	// ErrInvalidOutputDir is thrown when the output directory does not exist.
-	ErrInvalidOutputDir = errors.New("output directory was either blank or did not exist")
+	// ErrInvalidOutputDir is thrown when the output directory is not specified.
+	ErrInvalidOutputDir = errors.New("output directory was blank")
 )
 
 var defaultFilename = "generated_ecs.go"
@@ -37,11 +35,30 @@ type basic struct {
 	IncludeJSONMarshal bool
 }
 
-// New is a constructor for an empty debug output plugin.
+// Options configures the gostruct output plugin when it is created in code. The
+// fields have the same meaning as the plugin's CLI flags.
+type Options struct {
+	PackageName string
+	OutputDir   string
+	Filename    string
+	MarshalJSON bool
+}
+
+// New is a constructor for an empty gostruct output plugin.
 func New() generator.Generator {
 	return &basic{}
 }
 
+// NewWithOptions is a constructor for a gostruct output plugin that is configured in code.
+func NewWithOptions(opts Options) generator.Generator {
+	return &basic{
+		PackageName:        opts.PackageName,
+		OutputDir:          opts.OutputDir,
+		Filename:           opts.Filename,
+		IncludeJSONMarshal: opts.MarshalJSON,
+	}
+}
+
 // ID implements the generator.Generator interface.
 // Package: github.com/gen0cide/ecsgen/generator
 func (b *basic) ID() string {
@@ -60,7 +77,7 @@ func (b *basic) CLIFlags() []cli.Flag {
 		},
 		&cli.StringFlag{
 			Name:        "output-dir",
-			Usage:       "Path to the directory where the generated code should be written.",
+			Usage:       "Path to the directory where the generated code should be written, relative to the output.",
 			EnvVars:     []string{"OUTPUT_DIR"},
 			Destination: &b.OutputDir,
 		},
@@ -88,26 +105,14 @@ func (b *basic) Validate() error {
 		return ErrInvalidOutputDir
 	}
 
+	// The directory is relative to the output, which is not known here, so
+	// it's up to the output to create it, or to fail if it can't.
+
 	// Use the default unless otherwise specified
 	if b.Filename == "" {
 		b.Filename = defaultFilename
 	}
 
-	// Is it a valid path?
-	dir, err := os.Stat(b.OutputDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return ErrInvalidOutputDir
-		}
-
-		return fmt.Errorf("error locating specified output directory: %v", err)
-	}
-
-	// is it a valid directory?
-	if !dir.IsDir() {
-		return fmt.Errorf("specified output directory was a path to a file, not a directory")
-	}
-
 	// while Go maintains STRONG guidance on package naming conventions,
 	// it doesn't actually seem to enforce a whole lot. Keeping it basic for now.
 	pkgRegex := regexp.MustCompile(`^[a-zA-Z0-9\_]{1,64}$`)
@@ -118,8 +123,9 @@ func (b *basic) Validate() error {
 	return nil
 }
 
-// GoFieldType returns the Go type to be used in the Go struct field type definition.
-func GoFieldType(n *ecsgen.Node) string {
+// GoFieldType returns the Go type to be used in the Go struct field type definition. If the
+// Node's type has no Go translation, an *ecsgen.SchemaError wrapping ecsgen.ErrUnknownType is returned.
+func GoFieldType(n *ecsgen.Node) (string, error) {
 	// create a buffer to determine type
 	typeBuf := new(bytes.Buffer)
 
@@ -132,47 +138,63 @@ func GoFieldType(n *ecsgen.Node) string {
 	// Node("client.nat") needs to return "ClientNAT" as it's Go type.
 	if n.IsObject() {
 		typeBuf.WriteString(n.TypeIdent().Pascal())
-		return typeBuf.String()
+		return typeBuf.String(), nil
 	}
 
 	// Special cases denoted by the ECS developers.
 	switch {
 	case n.Name == "duration" && n.Definition.Type == "long":
 		typeBuf.WriteString("time.Duration")
-		return typeBuf.String()
+		return typeBuf.String(), nil
 	case n.Name == "args" && n.Definition.Type == "keyword":
 		typeBuf.WriteString("[]string")
-		return typeBuf.String()
+		return typeBuf.String(), nil
 	case n.Path == "labels":
 		typeBuf.WriteString("map[string]interface{}")
-		return typeBuf.String()
+		return typeBuf.String(), nil
 	}
 
 	// Find the right type!
 	switch n.Definition.Type {
-	case "keyword", "text", "ip", "geo_point":
+	case "keyword", "text", "ip", "geo_point", "geo_shape", "wildcard", "constant_keyword", "match_only_text", "version":
 		typeBuf.WriteString("string")
-		return typeBuf.String()
+		return typeBuf.String(), nil
 	case "long":
 		typeBuf.WriteString("int64")
-		return typeBuf.String()
+		return typeBuf.String(), nil
+	case "unsigned_long":
+		typeBuf.WriteString("uint64")
+		return typeBuf.String(), nil
 	case "integer":
 		typeBuf.WriteString("int32")
-		return typeBuf.String()
-	case "float":
+		return typeBuf.String(), nil
+	case "short":
+		typeBuf.WriteString("int16")
+		return typeBuf.String(), nil
+	case "byte":
+		typeBuf.WriteString("int8")
+		return typeBuf.String(), nil
+	case "float", "double", "half_float", "scaled_float":
 		typeBuf.WriteString("float64")
-		return typeBuf.String()
-	case "date":
+		return typeBuf.String(), nil
+	case "date", "date_nanos":
 		typeBuf.WriteString("time.Time")
-		return typeBuf.String()
+		return typeBuf.String(), nil
 	case "boolean":
 		typeBuf.WriteString("bool")
-		return typeBuf.String()
-	case "object":
+		return typeBuf.String(), nil
+	case "object", "flattened", "histogram":
 		typeBuf.WriteString("map[string]interface{}")
-		return typeBuf.String()
+		return typeBuf.String(), nil
+	case "nested":
+		typeBuf.WriteString("[]map[string]interface{}")
+		return typeBuf.String(), nil
 	default:
-		panic(fmt.Errorf("no translation for %v (field %s)", n.Definition.Type, n.Name))
+		return "", &ecsgen.SchemaError{
+			Path:  n.Path,
+			Field: n.Name,
+			Cause: fmt.Errorf("%w: no Go translation for %q", ecsgen.ErrUnknownType, n.Definition.Type),
+		}
 	}
 }
 
@@ -184,15 +206,9 @@ func (b *basic) ToGoCode(n *ecsgen.Node) (string, error) {
 		return "", fmt.Errorf("node %s is not an object", n.Path)
 	}
 
-	// Now enumerate the Node's fields and sort the keys so the resulting Go code
-	// is deterministically generated
-	fieldKeys := []string{}
-
-	for key := range n.Children {
-		fieldKeys = append(fieldKeys, key)
-	}
-
-	sort.Strings(fieldKeys)
+	// Now enumerate the Node's fields. They are already sorted by name, so
+	// the resulting Go code is deterministically generated
+	fields := n.ChildNodes()
 
 	// Create a new buffer to write the struct definition to
 	buf := new(strings.Builder)
@@ -205,13 +221,17 @@ func (b *basic) ToGoCode(n *ecsgen.Node) (string, error) {
 
 	// Enumerate the fields and generate their field definition, adding it
 	// to the buffer as a line item.
-	for _, k := range fieldKeys {
-		scalarField := n.Children[k]
+	for _, scalarField := range fields {
+		goType, err := GoFieldType(scalarField)
+		if err != nil {
+			return "", err
+		}
+
 		buf.WriteString(
 			fmt.Sprintf(
 				"\t%s %s `json:\"%s,omitempty\" yaml:\"%s,omitempty\" ecs:\"%s\"`",
 				scalarField.FieldIdent().Pascal(),
-				GoFieldType(scalarField),
+				goType,
 				scalarField.Name,
 				scalarField.Name,
 				scalarField.Path,
@@ -248,8 +268,7 @@ func (b *basic) ToGoCode(n *ecsgen.Node) (string, error) {
 		buf.WriteString("\n")
 
 		// enumerate the fields for the object fields
-		for _, fieldName := range fieldKeys {
-			field := n.Children[fieldName]
+		for _, field := range fields {
 			buf.WriteString(
 				fmt.Sprintf(
 					"\tif val := reflect.ValueOf(b.%s); !val.IsZero() {", field.FieldIdent().Pascal(),
@@ -284,20 +303,17 @@ func (b *basic) CreateBase(r *ecsgen.Root) (string, error) {
 	scalarFields := []string{}
 	objectFields := []string{}
 
-	// first we need to sort the field names, and separate out Base fields
-	// from the FieldSets
-	for fieldName, fieldNode := range r.TopLevel {
+	// separate out Base fields from the FieldSets. The top level
+	// nodes are already sorted by name.
+	for _, fieldNode := range r.ChildNodes() {
 		if fieldNode.IsObject() {
-			objectFields = append(objectFields, fieldName)
+			objectFields = append(objectFields, fieldNode.Name)
 			continue
 		}
 
-		scalarFields = append(scalarFields, fieldName)
+		scalarFields = append(scalarFields, fieldNode.Name)
 	}
 
-	sort.Strings(scalarFields)
-	sort.Strings(objectFields)
-
 	// now to build the buffer that holds the Go type definition
 	buf := new(strings.Builder)
 
@@ -311,11 +327,17 @@ func (b *basic) CreateBase(r *ecsgen.Root) (string, error) {
 	// and add them to the type definition
 	for _, k := range scalarFields {
 		field := r.TopLevel[k]
+
+		goType, err := GoFieldType(field)
+		if err != nil {
+			return "", err
+		}
+
 		buf.WriteString(
 			fmt.Sprintf(
 				"\t%s %s `json:\"%s,omitempty\" yaml:\"%s,omitempty\" ecs:\"%s\"`",
 				field.FieldIdent().Pascal(),
-				GoFieldType(field),
+				goType,
 
 				// We don't actually use the "parsed field name" here because
 				// unfortunately we have to account for the @timestamp field name
@@ -331,11 +353,17 @@ func (b *basic) CreateBase(r *ecsgen.Root) (string, error) {
 	// Now enumerate the object fields and add those to the base type
 	for _, k := range objectFields {
 		field := r.TopLevel[k]
+
+		goType, err := GoFieldType(field)
+		if err != nil {
+			return "", err
+		}
+
 		buf.WriteString(
 			fmt.Sprintf(
 				"\t%s %s `json:\"%s,omitempty\" yaml:\"%s,omitempty\" ecs:\"%s\"`",
 				field.FieldIdent().Pascal(),
-				GoFieldType(field),
+				goType,
 				field.Name,
 				field.Name,
 				field.Path,
@@ -420,7 +448,12 @@ func (b *basic) CreateBase(r *ecsgen.Root) (string, error) {
 
 // Execute implements the generator.Generator interface.
 // Package: github.com/gen0cide/ecsgen/generator
-func (b *basic) Execute(root *ecsgen.Root) error {
+func (b *basic) Execute(root *ecsgen.Root, out generator.Output) error {
+	// alias fields point at another field and never hold a value of their own
+	root = root.View(func(n *ecsgen.Node) bool {
+		return n.Definition.Type != "alias"
+	})
+
 	keys := []string{}
 
 	// enumerate through for all implied objects
@@ -445,7 +478,7 @@ func (b *basic) Execute(root *ecsgen.Root) error {
 	// Add the top level Base type definition at the top of the file
 	baseDef, err := b.CreateBase(root)
 	if err != nil {
-		return fmt.Errorf("error generating Base type definition: %v", err)
+		return fmt.Errorf("error generating Base type definition: %w", err)
 	}
 
 	buf.WriteString(baseDef)
@@ -454,10 +487,10 @@ func (b *basic) Execute(root *ecsgen.Root) error {
 	// Enumerate through all the objects, sorted by name alphabetically
 	// and add their type definitions to the buffer
 	for _, k := range keys {
-		obj := root.Branch(k)
+		obj, _ := root.Lookup(k)
 		code, err := b.ToGoCode(obj)
 		if err != nil {
-			return fmt.Errorf("error generating go code for %s: %v", k, err)
+			return fmt.Errorf("error generating go code for %s: %w", k, err)
 		}
 		buf.WriteString(code)
 	}
@@ -486,8 +519,8 @@ func (b *basic) Execute(root *ecsgen.Root) error {
 		return fmt.Errorf("error adding imports to generated go code: %v", err)
 	}
 
-	// Now write the resulting Go code to a file
-	err = ioutil.WriteFile(filepath.Join(b.OutputDir, b.Filename), imported, 0644)
+	// Now write the resulting Go code to the output
+	err = out.WriteFile(filepath.Join(b.OutputDir, b.Filename), imported)
 	if err != nil {
 		return fmt.Errorf("error writing go code to file: %v", err)
 	}
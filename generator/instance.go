@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/urfave/cli"
+)
+
+// InstanceSeparator separates a generator ID from the name of an instance of it.
+// For example, "gostruct:api" is the "api" instance of the gostruct generator.
+const InstanceSeparator = ":"
+
+// instance wraps a Generator with the options every generator has, such as its filters.
+// Named instances, such as "gostruct:api", are configured independently of the default
+// instance of the same generator, which has no name.
+type instance struct {
+	Generator
+
+	name    string
+	filters *filterOptions
+}
+
+// ID implements the generator.Generator interface. For named instances, it returns
+// the generator ID qualified by the instance name, i.e. "gostruct:api".
+func (i *instance) ID() string {
+	if i.name == "" {
+		return i.Generator.ID()
+	}
+
+	return i.Generator.ID() + InstanceSeparator + i.name
+}
+
+// CLIFlags implements the generator.Generator interface. It adds the
+// options every generator has to the generator's own flags.
+func (i *instance) CLIFlags() []cli.Flag {
+	return append(i.Generator.CLIFlags(), i.filters.flags()...)
+}
+
+// SplitInstance splits the name of a generator instance into the generator ID and the
+// instance name. For example, "gostruct:api" => ("gostruct", "api"). The instance name
+// is empty for the default instance, i.e. "gostruct" => ("gostruct", "").
+func SplitInstance(spec string) (string, string) {
+	parts := strings.SplitN(spec, InstanceSeparator, 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// NewInstance creates a named instance of a registered Generator. The spec is the generator ID
+// and instance name, i.e. "gostruct:api". Options are given in the form "name=value", where
+// name is the name of one of the generator's CLI flags (i.e. "package-name=api"), and are
+// applied in order. Options that are not given use the flag's default value, or the value of
+// the instance's environment variable (i.e. ECSGEN_OPT_GOSTRUCT_API_PACKAGE_NAME).
+func NewInstance(r Registry, spec string, options []string) (Generator, error) {
+	id, name := SplitInstance(spec)
+	if name == "" || strings.ContainsAny(name, ".=") {
+		return nil, fmt.Errorf("invalid generator instance %q: must be in the form id%sname", spec, InstanceSeparator)
+	}
+
+	base, err := r.New(id)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &instance{
+		Generator: base,
+		name:      name,
+		filters:   newFilterOptions(),
+	}
+
+	// bind the generator's flags to a flag set of its own, so options can be applied
+	// the same way the CLI applies them to the default instance.
+	instanceID := ecsgen.NewIdentifier(strings.Join([]string{id, name}, "-"))
+
+	set := flag.NewFlagSet(spec, flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+
+	original := g.CLIFlags()
+
+	flags, err := shimFlags(instanceID, original, true)
+	if err != nil {
+		return nil, fmt.Errorf("error creating options for %s: %v", spec, err)
+	}
+
+	for _, f := range flags {
+		err := f.Apply(set)
+		if err != nil {
+			return nil, fmt.Errorf("error creating options for %s: %v", spec, err)
+		}
+	}
+
+	for _, option := range options {
+		parts := strings.SplitN(option, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("option %q for %s must be in the form name=value", option, spec)
+		}
+
+		flagName := prefixName(instanceID, parts[0])
+		if set.Lookup(flagName) == nil {
+			return nil, fmt.Errorf("%s is not a valid option for %s", parts[0], spec)
+		}
+
+		err := set.Set(flagName, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for option %s of %s: %v", parts[1], parts[0], spec, err)
+		}
+	}
+
+	// required options must be given, either as an option or through the environment
+	given := map[string]bool{}
+	set.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	for idx, f := range flags {
+		if rf, ok := f.(cli.RequiredFlag); !ok || !rf.IsRequired() || f.IsSet() {
+			continue
+		}
+
+		if !anyGiven(given, f.Names()) {
+			return nil, fmt.Errorf("option %s of %s is required", original[idx].Names()[0], spec)
+		}
+	}
+
+	return g, nil
+}
+
+// anyGiven returns true if any of the names of a flag were given.
+func anyGiven(given map[string]bool, names []string) bool {
+	for _, name := range names {
+		if given[name] {
+			return true
+		}
+	}
+
+	return false
+}
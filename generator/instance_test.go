@@ -0,0 +1,234 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+// optionGenerator is a generator with a package name, a required module name and a count
+// option, each bound to the generator it was created for.
+type optionGenerator struct {
+	testGenerator
+
+	packageName string
+	moduleName  string
+	count       int
+}
+
+func (g *optionGenerator) CLIFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "package-name", EnvVars: []string{"PACKAGE_NAME"}, Value: "ecs", Destination: &g.packageName},
+		&cli.StringFlag{Name: "module-name", EnvVars: []string{"MODULE_NAME"}, Required: true, Destination: &g.moduleName},
+		&cli.IntFlag{Name: "count", Destination: &g.count},
+	}
+}
+
+func TestNewInstance(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		options     []string
+		env         map[string]string
+		wantID      string
+		wantPackage string
+		wantCount   int
+		wantFilters Filters
+		wantErr     string
+	}{
+		{
+			name:        "defaults",
+			spec:        "basic:api",
+			options:     []string{"module-name=api"},
+			wantID:      "basic:api",
+			wantPackage: "ecs",
+		},
+		{
+			name:        "options are applied in order",
+			spec:        "basic:api",
+			options:     []string{"module-name=api", "package-name=api", "count=1", "package-name=ecsapi", "count=2"},
+			wantID:      "basic:api",
+			wantPackage: "ecsapi",
+			wantCount:   2,
+		},
+		{
+			name:        "filter options",
+			spec:        "basic:api",
+			options:     []string{"module-name=api", "include=glob:process.*", "include=glob:host.*", "exclude=pid"},
+			wantID:      "basic:api",
+			wantPackage: "ecs",
+			wantFilters: Filters{Include: []string{"glob:process.*", "glob:host.*"}, Exclude: []string{"pid"}},
+		},
+		{
+			name:        "values may contain the separators",
+			spec:        "basic:api",
+			options:     []string{"module-name=api", "package-name=a=b:c"},
+			wantID:      "basic:api",
+			wantPackage: "a=b:c",
+		},
+		{
+			name:        "environment variables are prefixed with the instance",
+			spec:        "basic:api",
+			env:         map[string]string{"ECSGEN_OPT_BASIC_API_PACKAGE_NAME": "api", "ECSGEN_OPT_BASIC_API_MODULE_NAME": "api"},
+			wantID:      "basic:api",
+			wantPackage: "api",
+		},
+		{
+			name:        "options take precedence over environment variables",
+			spec:        "basic:api",
+			options:     []string{"package-name=ecsapi"},
+			env:         map[string]string{"ECSGEN_OPT_BASIC_API_PACKAGE_NAME": "api", "ECSGEN_OPT_BASIC_API_MODULE_NAME": "api"},
+			wantID:      "basic:api",
+			wantPackage: "ecsapi",
+		},
+		{
+			name:    "environment variables of the default instance are not used",
+			spec:    "basic:api",
+			env:     map[string]string{"ECSGEN_OPT_BASIC_MODULE_NAME": "api"},
+			wantErr: "option module-name of basic:api is required",
+		},
+		{
+			name:    "environment variables of another instance are not used",
+			spec:    "basic:api",
+			env:     map[string]string{"ECSGEN_OPT_BASIC_WEB_MODULE_NAME": "api"},
+			wantErr: "option module-name of basic:api is required",
+		},
+		{
+			name:    "missing required option",
+			spec:    "basic:api",
+			options: []string{"package-name=api"},
+			wantErr: "option module-name of basic:api is required",
+		},
+		{
+			name:    "no instance name",
+			spec:    "basic",
+			wantErr: `invalid generator instance "basic"`,
+		},
+		{
+			name:    "empty instance name",
+			spec:    "basic:",
+			wantErr: `invalid generator instance "basic:"`,
+		},
+		{
+			name:    "instance name with a dot",
+			spec:    "basic:api.v1",
+			wantErr: `invalid generator instance "basic:api.v1"`,
+		},
+		{
+			name:    "instance name with an equals sign",
+			spec:    "basic:api=v1",
+			wantErr: `invalid generator instance "basic:api=v1"`,
+		},
+		{
+			name:    "unknown generator",
+			spec:    "missing:api",
+			wantErr: "missing",
+		},
+		{
+			name:    "option without a value",
+			spec:    "basic:api",
+			options: []string{"module-name"},
+			wantErr: `option "module-name" for basic:api must be in the form name=value`,
+		},
+		{
+			name:    "unknown option",
+			spec:    "basic:api",
+			options: []string{"module-name=api", "output-dir=api"},
+			wantErr: "output-dir is not a valid option for basic:api",
+		},
+		{
+			name:    "prefixed option name",
+			spec:    "basic:api",
+			options: []string{"opt-basic-api-module-name=api"},
+			wantErr: "opt-basic-api-module-name is not a valid option for basic:api",
+		},
+		{
+			name:    "invalid value",
+			spec:    "basic:api",
+			options: []string{"module-name=api", "count=two"},
+			wantErr: `invalid value "two" for option count of basic:api`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, val := range tt.env {
+				t.Setenv(key, val)
+			}
+
+			r := NewRegistry()
+
+			err := r.Register(func() Generator {
+				return &optionGenerator{testGenerator: testGenerator{id: "basic"}}
+			})
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+
+			g, err := NewInstance(r, tt.spec, tt.options)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewInstance(%s) error = %v, want %q", tt.spec, err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewInstance(%s) error = %v", tt.spec, err)
+			}
+
+			if g.ID() != tt.wantID {
+				t.Errorf("ID() = %s, want %s", g.ID(), tt.wantID)
+			}
+
+			opts := g.(*instance).Generator.(*optionGenerator)
+			if opts.packageName != tt.wantPackage || opts.count != tt.wantCount {
+				t.Errorf("package-name = %q, count = %d, want %q, %d", opts.packageName, opts.count, tt.wantPackage, tt.wantCount)
+			}
+
+			if got := FiltersOf(g); !reflect.DeepEqual(got, tt.wantFilters) && !(got.IsEmpty() && tt.wantFilters.IsEmpty()) {
+				t.Errorf("FiltersOf() = %+v, want %+v", got, tt.wantFilters)
+			}
+		})
+	}
+}
+
+func TestNewInstanceIsolation(t *testing.T) {
+	r := NewRegistry()
+
+	err := r.Register(func() Generator {
+		return &optionGenerator{testGenerator: testGenerator{id: "basic"}}
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// every instance has options of its own, and none of them change the default instance
+	api, err := NewInstance(r, "basic:api", []string{"module-name=api", "package-name=api"})
+	if err != nil {
+		t.Fatalf("NewInstance(basic:api) error = %v", err)
+	}
+
+	web, err := NewInstance(r, "basic:web", []string{"module-name=web"})
+	if err != nil {
+		t.Fatalf("NewInstance(basic:web) error = %v", err)
+	}
+
+	base, err := r.Get("basic")
+	if err != nil {
+		t.Fatalf("Get(basic) error = %v", err)
+	}
+
+	got := []string{}
+	for _, g := range []Generator{api, web, base} {
+		opts := g.(*instance).Generator.(*optionGenerator)
+		got = append(got, opts.packageName+"/"+opts.moduleName)
+	}
+
+	if want := []string{"api/api", "ecs/web", "/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("options = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package generator
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOutsideOutput is returned when a file would be written outside the root of an Output,
+// such as "../generated.go".
+var ErrOutsideOutput = errors.New("path is outside of the output")
+
+// Output is where a generator writes the files it generates. The caller decides where the
+// files actually land, such as a directory, an archive or memory. Relative paths are relative
+// to the root of the Output. Implementations must be safe for concurrent use.
+type Output interface {
+	// WriteFile writes the complete contents of a single file, replacing
+	// any previous contents written to the same path.
+	WriteFile(name string, contents []byte) error
+}
+
+// DirOutput writes files to a directory on disk, creating any missing parent directories.
+// Files can only be written within the directory, so absolute paths must be inside it.
+type DirOutput struct {
+	Dir string
+}
+
+// NewDirOutput creates an Output that writes files relative to dir.
+func NewDirOutput(dir string) *DirOutput {
+	return &DirOutput{Dir: dir}
+}
+
+// WriteFile implements the generator.Output interface.
+func (d *DirOutput) WriteFile(name string, contents []byte) error {
+	dest, err := d.Path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating directory for %s: %v", dest, err)
+	}
+
+	return ioutil.WriteFile(dest, contents, 0644)
+}
+
+// Path returns the location on disk a file written to the Output ends up at. An error
+// wrapping ErrOutsideOutput is returned if the location is not within the directory.
+func (d *DirOutput) Path(name string) (string, error) {
+	dest := name
+	if !filepath.IsAbs(name) {
+		dest = filepath.Join(d.Dir, name)
+	}
+
+	// compare absolute paths, so "." and absolute names inside the directory both work
+	root, err := filepath.Abs(d.Dir)
+	if err != nil {
+		return "", fmt.Errorf("error resolving output directory %s: %v", d.Dir, err)
+	}
+
+	abs, err := filepath.Abs(dest)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s: %v", name, err)
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot write %s: %w", name, ErrOutsideOutput)
+	}
+
+	return dest, nil
+}
+
+// MemoryOutput holds the files written to it in memory. It is used for dry runs, checking
+// generated files against the ones on disk, and testing generators.
+type MemoryOutput struct {
+	sync.Mutex
+
+	files map[string][]byte
+}
+
+// NewMemoryOutput creates an empty MemoryOutput.
+func NewMemoryOutput() *MemoryOutput {
+	return &MemoryOutput{
+		files: map[string][]byte{},
+	}
+}
+
+// WriteFile implements the generator.Output interface.
+func (m *MemoryOutput) WriteFile(name string, contents []byte) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.files[name] = append([]byte{}, contents...)
+	return nil
+}
+
+// Files returns a copy of the files written so far, keyed by path.
+func (m *MemoryOutput) Files() map[string][]byte {
+	m.Lock()
+	defer m.Unlock()
+
+	ret := make(map[string][]byte, len(m.files))
+	for name, contents := range m.files {
+		ret[name] = contents
+	}
+
+	return ret
+}
+
+// Names returns the sorted paths of the files written so far.
+func (m *MemoryOutput) Names() []string {
+	m.Lock()
+	defer m.Unlock()
+
+	ret := make([]string, 0, len(m.files))
+	for name := range m.files {
+		ret = append(ret, name)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+// WriterOutput writes the contents of every file to a single io.Writer, one after another,
+// such as os.Stdout. The paths are discarded.
+type WriterOutput struct {
+	sync.Mutex
+
+	w io.Writer
+}
+
+// NewWriterOutput creates an Output that writes the contents of every file to w.
+func NewWriterOutput(w io.Writer) *WriterOutput {
+	return &WriterOutput{w: w}
+}
+
+// WriteFile implements the generator.Output interface.
+func (o *WriterOutput) WriteFile(_ string, contents []byte) error {
+	o.Lock()
+	defer o.Unlock()
+
+	_, err := o.w.Write(contents)
+	return err
+}
+
+// TarOutput writes files into a tar archive. Close must be called to complete the archive.
+type TarOutput struct {
+	sync.Mutex
+
+	tw *tar.Writer
+}
+
+// NewTarOutput creates an Output that writes a tar archive to w.
+func NewTarOutput(w io.Writer) *TarOutput {
+	return &TarOutput{tw: tar.NewWriter(w)}
+}
+
+// WriteFile implements the generator.Output interface.
+func (t *TarOutput) WriteFile(name string, contents []byte) error {
+	t.Lock()
+	defer t.Unlock()
+
+	entry, err := archiveName(name)
+	if err != nil {
+		return err
+	}
+
+	err = t.tw.WriteHeader(&tar.Header{
+		Name:    entry,
+		Mode:    0644,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = t.tw.Write(contents)
+	return err
+}
+
+// Close implements the io.Closer interface. It does not close the underlying io.Writer.
+func (t *TarOutput) Close() error {
+	return t.tw.Close()
+}
+
+// ZipOutput writes files into a zip archive. Close must be called to complete the archive.
+type ZipOutput struct {
+	sync.Mutex
+
+	zw *zip.Writer
+}
+
+// NewZipOutput creates an Output that writes a zip archive to w.
+func NewZipOutput(w io.Writer) *ZipOutput {
+	return &ZipOutput{zw: zip.NewWriter(w)}
+}
+
+// WriteFile implements the generator.Output interface.
+func (z *ZipOutput) WriteFile(name string, contents []byte) error {
+	z.Lock()
+	defer z.Unlock()
+
+	entry, err := archiveName(name)
+	if err != nil {
+		return err
+	}
+
+	fw, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     entry,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fw.Write(contents)
+	return err
+}
+
+// Close implements the io.Closer interface. It does not close the underlying io.Writer.
+func (z *ZipOutput) Close() error {
+	return z.zw.Close()
+}
+
+// archiveName converts a path into the relative, slash separated form archives use. Paths
+// that would be extracted outside the archive's directory are refused.
+func archiveName(name string) (string, error) {
+	entry := strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if entry == ".." || strings.HasPrefix(entry, "../") {
+		return "", fmt.Errorf("cannot write %s: %w", name, ErrOutsideOutput)
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,272 @@
+package generator
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDirOutputPath(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "ecs.go", want: filepath.Join(dir, "ecs.go")},
+		{name: "sub/ecs.go", want: filepath.Join(dir, "sub", "ecs.go")},
+		{name: "sub/../ecs.go", want: filepath.Join(dir, "ecs.go")},
+		{name: "..ecs.go", want: filepath.Join(dir, "..ecs.go")},
+		{name: filepath.Join(dir, "sub", "ecs.go"), want: filepath.Join(dir, "sub", "ecs.go")},
+		{name: "../ecs.go", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "sub/../../ecs.go", wantErr: true},
+		{name: filepath.Join(filepath.Dir(dir), "ecs.go"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDirOutput(dir).Path(tt.name)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOutsideOutput) {
+					t.Errorf("Path(%s) error = %v, want ErrOutsideOutput", tt.name, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Path(%s) error = %v", tt.name, err)
+			}
+
+			if filepath.Clean(got) != tt.want {
+				t.Errorf("Path(%s) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirOutputWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	out := NewDirOutput(filepath.Join(dir, "out"))
+
+	err := out.WriteFile("sub/ecs.go", []byte("package ecs"))
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "out", "sub", "ecs.go"))
+	if err != nil || string(contents) != "package ecs" {
+		t.Errorf("WriteFile() wrote %q, %v", contents, err)
+	}
+
+	err = out.WriteFile("../ecs.go", []byte("package ecs"))
+	if !errors.Is(err, ErrOutsideOutput) {
+		t.Errorf("WriteFile(../ecs.go) error = %v, want ErrOutsideOutput", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "ecs.go")); !os.IsNotExist(err) {
+		t.Errorf("WriteFile(../ecs.go) wrote outside the output")
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "ecs.go", want: "ecs.go"},
+		{name: "./sub/ecs.go", want: "sub/ecs.go"},
+		{name: "/sub/ecs.go", want: "sub/ecs.go"},
+		{name: "sub//ecs.go", want: "sub/ecs.go"},
+		{name: "sub/../ecs.go", want: "ecs.go"},
+		{name: "..ecs.go", want: "..ecs.go"},
+		{name: "..", wantErr: true},
+		{name: "../ecs.go", wantErr: true},
+		{name: "sub/../../ecs.go", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := archiveName(tt.name)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOutsideOutput) {
+					t.Errorf("archiveName(%s) error = %v, want ErrOutsideOutput", tt.name, err)
+				}
+
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("archiveName(%s) = %s, %v, want %s", tt.name, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveOutputs(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(w io.Writer) (Output, io.Closer)
+		read func(data []byte) (map[string]string, error)
+	}{
+		{
+			name: "tar",
+			open: func(w io.Writer) (Output, io.Closer) {
+				out := NewTarOutput(w)
+				return out, out
+			},
+			read: readTar,
+		},
+		{
+			name: "zip",
+			open: func(w io.Writer) (Output, io.Closer) {
+				out := NewZipOutput(w)
+				return out, out
+			},
+			read: readZip,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			out, closer := tt.open(buf)
+
+			for name, contents := range map[string]string{"ecs.go": "package ecs", "./sub/ecs.go": ""} {
+				err := out.WriteFile(name, []byte(contents))
+				if err != nil {
+					t.Fatalf("WriteFile(%s) error = %v", name, err)
+				}
+			}
+
+			err := out.WriteFile("../ecs.go", []byte("package ecs"))
+			if !errors.Is(err, ErrOutsideOutput) {
+				t.Errorf("WriteFile(../ecs.go) error = %v, want ErrOutsideOutput", err)
+			}
+
+			err = closer.Close()
+			if err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			got, err := tt.read(buf.Bytes())
+			if err != nil {
+				t.Fatalf("error reading the archive: %v", err)
+			}
+
+			want := map[string]string{"ecs.go": "package ecs", "sub/ecs.go": ""}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("archive = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// readTar returns the contents of every file in a tar archive, keyed by name.
+func readTar(data []byte) (map[string]string, error) {
+	ret := map[string]string{}
+
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return ret, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		ret[header.Name] = string(contents)
+	}
+}
+
+// readZip returns the contents of every file in a zip archive, keyed by name.
+func readZip(data []byte) (map[string]string, error) {
+	ret := map[string]string{}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range zr.File {
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		ret[file.Name] = string(contents)
+	}
+
+	return ret, nil
+}
+
+func TestMemoryOutput(t *testing.T) {
+	out := NewMemoryOutput()
+
+	contents := []byte("package ecs")
+
+	for _, name := range []string{"sub/ecs.go", "ecs.go", "ecs.go"} {
+		err := out.WriteFile(name, contents)
+		if err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+	}
+
+	// the contents are copied, so later changes to the slice are not seen
+	contents[0] = 'P'
+
+	if got, want := out.Names(), []string{"ecs.go", "sub/ecs.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+
+	files := out.Files()
+	if string(files["ecs.go"]) != "package ecs" || string(files["sub/ecs.go"]) != "package ecs" {
+		t.Errorf("Files() = %q", files)
+	}
+
+	// Files returns a copy of the map
+	delete(files, "ecs.go")
+	if len(out.Files()) != 2 {
+		t.Errorf("Files() is not a copy")
+	}
+}
+
+func TestWriterOutput(t *testing.T) {
+	buf := new(bytes.Buffer)
+	out := NewWriterOutput(buf)
+
+	// the paths are discarded, and the contents written in order
+	for _, name := range []string{"a", "b"} {
+		err := out.WriteFile(name+".go", []byte("package "+name+"\n"))
+		if err != nil {
+			t.Fatalf("WriteFile(%s.go) error = %v", name, err)
+		}
+	}
+
+	if got := buf.String(); got != "package a\npackage b\n" {
+		t.Errorf("WriteFile() wrote %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/urfave/cli"
@@ -10,12 +11,17 @@ import (
 // Registry is a type that is used to hold all the output generators. This is similar
 // to common plugin registry patterns in other go code.
 type Registry interface {
-	// Register is used to add a Generator to the Registry.
-	Register(Generator) error
+	// Register is used to add a Generator to the Registry. The Factory is called once
+	// to create the default instance, which is keyed by its ID. It fails if the CLI flags
+	// of the Generator cannot be prefixed with its ID.
+	Register(Factory) error
 
-	// Get is used to retrieve a Generator from the Registry.
+	// Get is used to retrieve the default instance of a Generator from the Registry.
 	Get(string) (Generator, error)
 
+	// New is used to create a new, unconfigured instance of a Generator in the Registry.
+	New(string) (Generator, error)
+
 	// All is used to retrieve all of the generators in the registry.
 	All() []Generator
 
@@ -27,15 +33,21 @@ type Registry interface {
 type registry struct {
 	sync.RWMutex
 
-	store   map[string]Generator
-	ordered []Generator
+	store     map[string]Generator
+	factories map[string]Factory
+	ordered   []Generator
+
+	// flags holds the shimmed CLI flags of the default instances, in the order they were registered.
+	flags []cli.Flag
 }
 
 // NewRegistry is used to initialize a new registry.
 func NewRegistry() Registry {
 	return &registry{
-		store:   map[string]Generator{},
-		ordered: []Generator{},
+		store:     map[string]Generator{},
+		factories: map[string]Factory{},
+		ordered:   []Generator{},
+		flags:     []cli.Flag{},
 	}
 }
 
@@ -51,17 +63,46 @@ func (r *registry) Get(id string) (Generator, error) {
 	return nil, fmt.Errorf("generator %s could not be found in the registry", id)
 }
 
+// New implements the generator.Registry interface.
+func (r *registry) New(id string) (Generator, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if factory, found := r.factories[id]; found {
+		return factory(), nil
+	}
+
+	return nil, fmt.Errorf("generator %s could not be found in the registry", id)
+}
+
 // Register implements the generator.Registry interface.
-func (r *registry) Register(g Generator) error {
+func (r *registry) Register(factory Factory) error {
 	r.Lock()
 	defer r.Unlock()
 
+	g := &instance{
+		Generator: factory(),
+		filters:   newFilterOptions(),
+	}
+
 	if _, found := r.store[g.ID()]; found {
 		return fmt.Errorf("generator %s has already been registered", g.ID())
 	}
 
+	if strings.ContainsAny(g.ID(), InstanceSeparator+".") {
+		return fmt.Errorf("generator ID %s cannot contain %q or \".\"", g.ID(), InstanceSeparator)
+	}
+
+	// the flags are shimmed once, so a generator with flags that cannot be prefixed fails here
+	flags, err := shimCLIFlags(g)
+	if err != nil {
+		return fmt.Errorf("generator %s: %v", g.ID(), err)
+	}
+
 	r.store[g.ID()] = g
+	r.factories[g.ID()] = factory
 	r.ordered = append(r.ordered, g)
+	r.flags = append(r.flags, flags...)
 	return nil
 }
 
@@ -79,20 +120,12 @@ func (r *registry) All() []Generator {
 
 // CLIFlags implements the generator.Registry interface.
 func (r *registry) CLIFlags() []cli.Flag {
-	// short circuit if we have no plugins registered
-	if len(r.ordered) == 0 {
-		return []cli.Flag{}
-	}
-
 	r.Lock()
 	defer r.Unlock()
 
-	res := []cli.Flag{}
-
-	for _, generator := range r.ordered {
-		flags := shimCLIFlags(generator)
-		res = append(res, flags...)
-	}
+	// make a copy, don't just return our slice
+	ret := make([]cli.Flag, len(r.flags))
+	copy(ret, r.flags)
 
-	return res
+	return ret
 }
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"context"
+	"strings"
+
+	texttemplate "text/template"
+
+	"github.com/gen0cide/ecsgen"
+)
+
+// funcs returns the functions available to every template. The case helpers take any string,
+// such as a Node's Name or Path, and return it in the case of the ecsgen.Identifier method of
+// the same name. The tree helpers use root, which is nil while the templates are parsed.
+func funcs(root *ecsgen.Root) texttemplate.FuncMap {
+	// identifiers keep the initialisms of the schema capitalized
+	ident := ecsgen.NewIdentifier
+	if root != nil {
+		ident = root.Identifier
+	}
+
+	return texttemplate.FuncMap{
+		// identifier case helpers
+		"ident": ident,
+		"snake": func(s string) string {
+			return ident(s).Snake()
+		},
+		"pascal": func(s string) string {
+			return ident(s).Pascal()
+		},
+		"camel": func(s string) string {
+			return ident(s).Camel()
+		},
+		"screaming": func(s string) string {
+			return ident(s).Screaming()
+		},
+		"command": func(s string) string {
+			return ident(s).Command()
+		},
+		"train": func(s string) string {
+			return ident(s).Train()
+		},
+		"dotted": func(s string) string {
+			return ident(s).Dotted()
+		},
+
+		// tree helpers
+		"lookup": func(path string) *ecsgen.Node {
+			node, _ := root.Lookup(path)
+			return node
+		},
+		"query": func(expr string) ([]*ecsgen.Node, error) {
+			return root.Select(expr)
+		},
+		"walk": walk,
+
+		// string helpers
+		"join":       strings.Join,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"replace":    strings.ReplaceAll,
+		"trimPrefix": strings.TrimPrefix,
+		"trimSuffix": strings.TrimSuffix,
+	}
+}
+
+// walk returns every Node beneath a Root or Node, depth first, in sorted order.
+func walk(w ecsgen.Walkable) ([]*ecsgen.Node, error) {
+	ret := []*ecsgen.Node{}
+
+	walker := &ecsgen.Walker{
+		Enter: func(n *ecsgen.Node, _ int) error {
+			ret = append(ret, n)
+			return nil
+		},
+	}
+
+	err := walker.Walk(context.Background(), w)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,375 @@
+// Package template implements an output plugin that renders Go text/template files against the
+// loaded schema, either once or once for every fieldset or object (see the fan-out option).
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	texttemplate "text/template"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/generator"
+	"github.com/urfave/cli"
+)
+
+const (
+	// FanOutNone renders each template once, for the whole schema.
+	FanOutNone = "none"
+
+	// FanOutFieldset renders each template once for every fieldset of the schema that is in
+	// the tree at the top level, including root fieldsets such as "base". It needs a source
+	// format with fieldsets, such as nested or schemas.
+	FanOutFieldset = "fieldset"
+
+	// FanOutObject renders each template once for every object in the schema.
+	FanOutObject = "object"
+
+	// templateExt is removed from the name of a template file to get the name of the output.
+	templateExt = ".tmpl"
+)
+
+var (
+	// ErrNoTemplates is thrown when no template files were specified.
+	ErrNoTemplates = errors.New("at least one template file must be specified")
+
+	// FanOuts is the list of supported ways to render a template more than once.
+	FanOuts = []string{
+		FanOutNone,
+		FanOutFieldset,
+		FanOutObject,
+	}
+)
+
+// Data is passed to every template, as well as the filename template.
+type Data struct {
+	// Root is the schema tree.
+	Root *ecsgen.Root
+
+	// Node is the object the template is rendered for. It is nil when the template is not
+	// fanned out, or when it is rendered for a root fieldset, which has no object.
+	Node *ecsgen.Node
+
+	// Fieldset is the fieldset the template is rendered for. It is only set when the
+	// template is fanned out by fieldset.
+	Fieldset *ecsgen.Fieldset
+
+	// Template is the name of the template file, without its directory and ".tmpl" extension.
+	Template string
+
+	// Params holds the key=value parameters given to the plugin.
+	Params map[string]string
+}
+
+type tmpl struct {
+	Templates []string
+	OutputDir string
+	Filename  string
+	FanOut    string
+
+	templates *cli.StringSlice
+	params    *cli.StringSlice
+	options   map[string]string
+	parsed    []*texttemplate.Template
+	filename  *texttemplate.Template
+}
+
+// Options configures the template output plugin when it is created in code. The
+// fields have the same meaning as the plugin's CLI flags.
+type Options struct {
+	Templates []string
+	OutputDir string
+	Filename  string
+	FanOut    string
+	Params    map[string]string
+}
+
+// New is a constructor for an empty template output plugin.
+func New() generator.Generator {
+	return &tmpl{
+		templates: cli.NewStringSlice(),
+		params:    cli.NewStringSlice(),
+	}
+}
+
+// NewWithOptions is a constructor for a template output plugin that is configured in code.
+func NewWithOptions(opts Options) generator.Generator {
+	params := []string{}
+	for key, val := range opts.Params {
+		params = append(params, fmt.Sprintf("%s=%s", key, val))
+	}
+
+	return &tmpl{
+		Templates: opts.Templates,
+		OutputDir: opts.OutputDir,
+		Filename:  opts.Filename,
+		FanOut:    opts.FanOut,
+		templates: cli.NewStringSlice(),
+		params:    cli.NewStringSlice(params...),
+	}
+}
+
+// ID implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (t *tmpl) ID() string {
+	return "template"
+}
+
+// CLIFlags implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (t *tmpl) CLIFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        "template",
+			Usage:       "Path to a Go text/template file to render. (Can be used multiple times).",
+			EnvVars:     []string{"TEMPLATE"},
+			Required:    true,
+			Value:       t.templates,
+			Destination: t.templates,
+		},
+		&cli.StringFlag{
+			Name:        "output-dir",
+			Usage:       "Path to the directory where the rendered files should be written, relative to the output.",
+			EnvVars:     []string{"OUTPUT_DIR"},
+			Value:       ".",
+			Destination: &t.OutputDir,
+		},
+		&cli.StringFlag{
+			Name:        "filename",
+			Usage:       "Template for the name of each rendered file (i.e. \"{{ .Node.Name | snake }}.go\"). (default: the template name, prefixed by the fieldset name or object path when fanned out)",
+			EnvVars:     []string{"FILENAME"},
+			Destination: &t.Filename,
+		},
+		&cli.StringFlag{
+			Name:        "fan-out",
+			Usage:       fmt.Sprintf("Render each template once per fieldset or object, instead of once. Possible values: %s", strings.Join(FanOuts, ", ")),
+			EnvVars:     []string{"FAN_OUT"},
+			Value:       FanOutNone,
+			Destination: &t.FanOut,
+		},
+		&cli.StringSliceFlag{
+			Name:        "param",
+			Usage:       "Parameter available to the templates as .Params, in the form key=value. (Can be used multiple times).",
+			EnvVars:     []string{"PARAM"},
+			Value:       t.params,
+			Destination: t.params,
+		},
+	}
+}
+
+// Validate implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (t *tmpl) Validate() error {
+	files := append(append([]string{}, t.Templates...), t.templates.Value()...)
+	if len(files) == 0 {
+		return ErrNoTemplates
+	}
+
+	// Use the defaults unless otherwise specified
+	if t.OutputDir == "" {
+		t.OutputDir = "."
+	}
+
+	if t.FanOut == "" {
+		t.FanOut = FanOutNone
+	}
+
+	switch t.FanOut {
+	case FanOutNone, FanOutFieldset, FanOutObject:
+	default:
+		return fmt.Errorf("%s is not a valid fan-out. valid options: %s", t.FanOut, strings.Join(FanOuts, ", "))
+	}
+
+	if t.Filename == "" {
+		switch t.FanOut {
+		case FanOutFieldset:
+			t.Filename = "{{ .Fieldset.Name | snake }}_{{ .Template }}"
+		case FanOutObject:
+			t.Filename = "{{ .Node.Path | snake }}_{{ .Template }}"
+		default:
+			t.Filename = "{{ .Template }}"
+		}
+	}
+
+	t.options = map[string]string{}
+
+	for _, param := range t.params.Value() {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("parameter %q must be in the form key=value", param)
+		}
+
+		t.options[parts[0]] = parts[1]
+	}
+
+	filename, err := texttemplate.New("filename").Funcs(funcs(nil)).Option("missingkey=error").Parse(t.Filename)
+	if err != nil {
+		return fmt.Errorf("error parsing filename template: %v", err)
+	}
+
+	t.filename = filename
+	t.parsed = []*texttemplate.Template{}
+
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading template: %v", err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), templateExt)
+
+		parsed, err := texttemplate.New(name).Funcs(funcs(nil)).Option("missingkey=error").Parse(string(contents))
+		if err != nil {
+			return fmt.Errorf("error parsing template %s: %v", file, err)
+		}
+
+		t.parsed = append(t.parsed, parsed)
+	}
+
+	return nil
+}
+
+// Execute implements the generator.Generator interface.
+// Package: github.com/gen0cide/ecsgen/generator
+func (t *tmpl) Execute(r *ecsgen.Root, out generator.Output) error {
+	targets := t.fanOut(r)
+	written := map[string]string{}
+
+	for _, parsed := range t.parsed {
+		for _, target := range targets {
+			data := &Data{
+				Root:     r,
+				Node:     target.Node,
+				Fieldset: target.Fieldset,
+				Template: parsed.Name(),
+				Params:   t.options,
+			}
+
+			rendered, err := render(t.filename, r, data)
+			if err != nil {
+				return fmt.Errorf("error rendering filename for %s: %v", describe(data), err)
+			}
+
+			name := path.Join(t.OutputDir, strings.TrimSpace(string(rendered)))
+
+			// fanned out files need distinct names, or they would overwrite each other
+			if previous, found := written[name]; found {
+				return fmt.Errorf("%s and %s both render to %s", previous, describe(data), name)
+			}
+
+			written[name] = describe(data)
+
+			contents, err := render(parsed, r, data)
+			if err != nil {
+				return fmt.Errorf("error rendering %s: %v", describe(data), err)
+			}
+
+			err = out.WriteFile(name, contents)
+			if err != nil {
+				return fmt.Errorf("error writing %s: %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fanOut returns the Data each template is rendered for, with only the Node and Fieldset set.
+// A single empty Data means the template is rendered once, for the whole schema.
+func (t *tmpl) fanOut(r *ecsgen.Root) []*Data {
+	switch t.FanOut {
+	case FanOutFieldset:
+		names := []string{}
+		for name := range r.Fieldsets {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		ret := []*Data{}
+		for _, name := range names {
+			fieldset := r.Fieldsets[name]
+
+			// root fieldsets place their fields at the top level, so they have no object
+			if fieldset.Root {
+				if hasFields(r, fieldset) {
+					ret = append(ret, &Data{Fieldset: fieldset})
+				}
+
+				continue
+			}
+
+			// fieldsets that are only reused, or were filtered out, are not at the top level
+			if node, found := r.Index[name]; found && node.Fieldset == fieldset {
+				ret = append(ret, &Data{Node: node, Fieldset: fieldset})
+			}
+		}
+
+		return ret
+	case FanOutObject:
+		ret := []*Data{}
+
+		// walking the tree cannot fail, as the callback never returns an error
+		nodes, _ := walk(r)
+		for _, node := range nodes {
+			if node.IsObject() {
+				ret = append(ret, &Data{Node: node})
+			}
+		}
+
+		return ret
+	default:
+		return []*Data{{}}
+	}
+}
+
+// hasFields returns true if any field of a fieldset is in the tree.
+func hasFields(r *ecsgen.Root, fieldset *ecsgen.Fieldset) bool {
+	for key, def := range fieldset.Fields {
+		flatName := def.FlatName
+		if flatName == "" {
+			flatName = fieldset.Prefix + key
+		}
+
+		if _, found := r.Index[flatName]; found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// render executes a parsed template with the tree helpers bound to r.
+func render(parsed *texttemplate.Template, r *ecsgen.Root, data *Data) ([]byte, error) {
+	bound, err := parsed.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+
+	err = bound.Funcs(funcs(r)).Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// describe returns a description of the template and Node or Fieldset being rendered, for errors.
+func describe(data *Data) string {
+	switch {
+	case data.Node != nil:
+		return fmt.Sprintf("template %s for %s", data.Template, data.Node.Path)
+	case data.Fieldset != nil:
+		return fmt.Sprintf("template %s for fieldset %s", data.Template, data.Fieldset.Name)
+	default:
+		return fmt.Sprintf("template %s", data.Template)
+	}
+}
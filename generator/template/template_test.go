@@ -0,0 +1,184 @@
+package template
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/generator"
+)
+
+// testRoot returns a tree with a root fieldset, a top level fieldset with a reused fieldset
+// nested in it, an implied object without a fieldset, and a fieldset that is not in the tree.
+func testRoot(t *testing.T) *ecsgen.Root {
+	r := ecsgen.NewRoot()
+	r.Fieldsets["base"] = &ecsgen.Fieldset{
+		Name:   "base",
+		Root:   true,
+		Fields: map[string]*ecsgen.Definition{"@timestamp": {FlatName: "@timestamp"}},
+	}
+	r.Fieldsets["client"] = &ecsgen.Fieldset{Name: "client", Prefix: "client."}
+	r.Fieldsets["geo"] = &ecsgen.Fieldset{Name: "geo", Prefix: "geo.", Reusable: &ecsgen.Reusable{}}
+	r.Fieldsets["process"] = &ecsgen.Fieldset{Name: "process", Prefix: "process."}
+
+	for _, id := range []string{"@timestamp", "client.address", "client.geo.city_name", "cloud.account.id"} {
+		node, err := r.Branch(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node.Definition = &ecsgen.Definition{FlatName: id, Type: "keyword"}
+	}
+
+	r.Index["client"].Fieldset = r.Fieldsets["client"]
+	r.Index["client.geo"].Fieldset = r.Fieldsets["geo"]
+
+	return r
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		opts        Options
+		wantFiles   map[string]string
+		wantInvalid string
+		wantErr     string
+	}{
+		{
+			name:     "single file",
+			template: `{{ .Params.pkg }}:{{ range walk .Root }}{{ if not .IsObject }} {{ pascal .Path }}{{ end }}{{ end }}`,
+			opts:     Options{OutputDir: "out", Params: map[string]string{"pkg": "ecs"}},
+			wantFiles: map[string]string{
+				"out/fields.txt": "ecs: AtTimestamp ClientAddress ClientGeoCityName CloudAccountID",
+			},
+		},
+		{
+			name:     "fan out by fieldset",
+			template: `{{ .Fieldset.Name }}:{{ with .Node }}{{ .Path }}{{ end }}`,
+			opts:     Options{FanOut: FanOutFieldset},
+			wantFiles: map[string]string{
+				"base_fields.txt":   "base:",
+				"client_fields.txt": "client:client",
+			},
+		},
+		{
+			name:     "fan out by object",
+			template: `{{ len .Node.ChildNodes }}`,
+			opts:     Options{FanOut: FanOutObject},
+			wantFiles: map[string]string{
+				"client_fields.txt":        "2",
+				"client_geo_fields.txt":    "1",
+				"cloud_fields.txt":         "1",
+				"cloud_account_fields.txt": "1",
+			},
+		},
+		{
+			name:     "filename template",
+			template: `{{ .Node.Path }}`,
+			opts:     Options{FanOut: FanOutObject, Filename: `{{ .Node.Path | dotted }}.{{ .Template }}`},
+			wantFiles: map[string]string{
+				"client.fields.txt":        "client",
+				"client.geo.fields.txt":    "client.geo",
+				"cloud.fields.txt":         "cloud",
+				"cloud.account.fields.txt": "cloud.account",
+			},
+		},
+		{
+			name:        "no templates",
+			wantInvalid: "at least one template file must be specified",
+		},
+		{
+			name:        "unknown fan out",
+			template:    `{{ .Template }}`,
+			opts:        Options{FanOut: "fields"},
+			wantInvalid: "fields is not a valid fan-out",
+		},
+		{
+			name:        "template syntax error",
+			template:    `{{ range .Root }}`,
+			wantInvalid: "error parsing template",
+		},
+		{
+			name:        "filename syntax error",
+			template:    `{{ .Template }}`,
+			opts:        Options{Filename: `{{ .Template`},
+			wantInvalid: "error parsing filename template",
+		},
+		{
+			name:        "invalid parameter",
+			template:    `{{ .Template }}`,
+			opts:        Options{Params: map[string]string{"": "ecs"}},
+			wantInvalid: "must be in the form key=value",
+		},
+		{
+			name:     "missing parameter",
+			template: `{{ .Params.pkg }}`,
+			wantErr:  "error rendering template fields.txt",
+		},
+		{
+			name:     "files rendered to the same name",
+			template: `{{ .Fieldset.Name }}`,
+			opts:     Options{FanOut: FanOutFieldset, Filename: "{{ .Template }}"},
+			wantErr:  "template fields.txt for fieldset base and template fields.txt for client both render to fields.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if tt.template != "" {
+				file := filepath.Join(t.TempDir(), "fields.txt.tmpl")
+
+				err := ioutil.WriteFile(file, []byte(tt.template), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				opts.Templates = []string{file}
+			}
+
+			g := NewWithOptions(opts)
+
+			err := g.Validate()
+			if tt.wantInvalid != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantInvalid) {
+					t.Errorf("Validate() error = %v, want %q", err, tt.wantInvalid)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			out := generator.NewMemoryOutput()
+
+			err = g.Execute(testRoot(t), out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			got := map[string]string{}
+			for name, contents := range out.Files() {
+				got[name] = string(contents)
+			}
+
+			if !reflect.DeepEqual(got, tt.wantFiles) {
+				t.Errorf("Execute() wrote %q, want %q", got, tt.wantFiles)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package ecsgen
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/gen0cide/flect"
 	"github.com/go-openapi/swag"
@@ -12,7 +13,8 @@ import (
 type Initialisms []string
 
 // DefaultInitialisms are the default initialisms we set. You can set more via init functions
-// by calling the AddIdentifierInitialism function.
+// by calling the AddIdentifierInitialism function, which adds them for every Identifier, or
+// use Initialisms.Identifier to only keep them for a single Identifier.
 var DefaultInitialisms = Initialisms{
 	"AMI",
 	"PPID",
@@ -46,14 +48,28 @@ func init() {
 	swag.AddInitialisms([]string(DefaultInitialisms)...)
 }
 
-// AddIdentifierInitialism is used to add custom initializations into the library.
+// AddIdentifierInitialism is used to add custom initializations into the library. They are
+// added for every Identifier in the process, so prefer Root.Initialisms for the initialisms
+// of a single schema.
 func AddIdentifierInitialism(s ...string) {
 	swag.AddInitialisms(s...)
 }
 
+// Identifier creates an Identifier from a provided string, which keeps the initialisms
+// capitalized in addition to the DefaultInitialisms.
+func (i Initialisms) Identifier(s string) Identifier {
+	return Identifier{
+		original:    s,
+		initialisms: i,
+	}
+}
+
 // Identifier is used to create various cases and pluralities of the enums being generated.
 type Identifier struct {
 	original string
+
+	// initialisms are kept capitalized in addition to the DefaultInitialisms.
+	initialisms Initialisms
 }
 
 // NewIdentifier creates a new identifier from a provided string.
@@ -70,7 +86,7 @@ func (i Identifier) Snake() string {
 
 // Pascal returns the identifiers PascalCase representation.
 func (i Identifier) Pascal() string {
-	return swag.ToGoName(i.original)
+	return i.capitalize(swag.ToGoName(i.original))
 }
 
 // Screaming returns the identifiers SCREAMING_CASE representation.
@@ -85,7 +101,7 @@ func (i Identifier) Command() string {
 
 // Camel returns the identifiers camelCase representation.
 func (i Identifier) Camel() string {
-	return swag.ToVarName(i.original)
+	return i.capitalize(swag.ToVarName(i.original))
 }
 
 // Train returns the identifiers TRAIN-CASE representation.
@@ -102,3 +118,41 @@ func (i Identifier) Dotted() string {
 func (i Identifier) Ident() flect.Ident {
 	return flect.New(i.original)
 }
+
+// capitalize replaces every word of a PascalCase or camelCase string that is one of the
+// Identifier's initialisms, in the case swag gives words it does not know, with the initialism.
+func (i Identifier) capitalize(s string) string {
+	for _, initialism := range i.initialisms {
+		word := swag.ToGoName(strings.ToLower(initialism))
+		if word == "" || word == initialism {
+			continue
+		}
+
+		buf := new(strings.Builder)
+
+		for {
+			idx := strings.Index(s, word)
+			if idx < 0 {
+				buf.WriteString(s)
+				break
+			}
+
+			end := idx + len(word)
+
+			// the next word starts with a capital letter, so a lowercase letter means the
+			// match is only the start of a longer word
+			if end < len(s) && unicode.IsLower(rune(s[end])) {
+				buf.WriteString(s[:end])
+			} else {
+				buf.WriteString(s[:idx])
+				buf.WriteString(initialism)
+			}
+
+			s = s[end:]
+		}
+
+		s = buf.String()
+	}
+
+	return s
+}
@@ -0,0 +1,70 @@
+package ecsgen
+
+import (
+	"testing"
+)
+
+func TestIdentifierInitialisms(t *testing.T) {
+	initialisms := Initialisms{"K8S", "GCP"}
+
+	tests := []struct {
+		original   string
+		wantPascal string
+		wantCamel  string
+	}{
+		{original: "k8s", wantPascal: "K8S", wantCamel: "k8s"},
+		{original: "kubernetes.k8s_cluster", wantPascal: "KubernetesK8SCluster", wantCamel: "kubernetesK8SCluster"},
+		{original: "cloud.gcp.k8s", wantPascal: "CloudGCPK8S", wantCamel: "cloudGCPK8S"},
+		{original: "k8sx.name", wantPascal: "K8sxName", wantCamel: "k8sxName"},
+		{original: "process.pid", wantPascal: "ProcessPID", wantCamel: "processPID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.original, func(t *testing.T) {
+			ident := initialisms.Identifier(tt.original)
+
+			if got := ident.Pascal(); got != tt.wantPascal {
+				t.Errorf("Pascal() = %s, want %s", got, tt.wantPascal)
+			}
+
+			if got := ident.Camel(); got != tt.wantCamel {
+				t.Errorf("Camel() = %s, want %s", got, tt.wantCamel)
+			}
+		})
+	}
+}
+
+func TestIdentifierInitialismsScope(t *testing.T) {
+	_ = Initialisms{"K8S"}.Identifier("k8s").Pascal()
+
+	// the initialisms only apply to the Identifiers created with them
+	if got := NewIdentifier("k8s").Pascal(); got != "K8s" {
+		t.Errorf("NewIdentifier(\"k8s\").Pascal() = %s, want K8s", got)
+	}
+}
+
+func TestNodeIdentInitialisms(t *testing.T) {
+	r := NewRoot()
+	r.Initialisms = Initialisms{"K8S"}
+
+	node, err := r.Branch("orchestrator.k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node.Definition = &Definition{Type: "keyword"}
+
+	if got := node.TypeIdent().Pascal(); got != "OrchestratorK8S" {
+		t.Errorf("TypeIdent().Pascal() = %s, want OrchestratorK8S", got)
+	}
+
+	if got := node.FieldIdent().Pascal(); got != "K8S" {
+		t.Errorf("FieldIdent().Pascal() = %s, want K8S", got)
+	}
+
+	// views keep the initialisms of the tree they were created from
+	view := r.View(func(*Node) bool { return true })
+	if got := view.Index["orchestrator.k8s"].FieldIdent().Pascal(); got != "K8S" {
+		t.Errorf("FieldIdent().Pascal() in a view = %s, want K8S", got)
+	}
+}
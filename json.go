@@ -0,0 +1,139 @@
+package ecsgen
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// rootJSON is the JSON representation of a Root.
+type rootJSON struct {
+	Initialisms Initialisms          `json:"initialisms,omitempty"`
+	Fieldsets   map[string]*Fieldset `json:"fieldsets,omitempty"`
+	Children    []*Node              `json:"children"`
+}
+
+// nodeJSON is the JSON representation of a Node. The Parent and Root are implied by the
+// Node's position in the tree, and the Fieldset is referenced by name.
+type nodeJSON struct {
+	Name       string      `json:"name"`
+	Path       string      `json:"path"`
+	Object     bool        `json:"object"`
+	Array      bool        `json:"array"`
+	Definition *Definition `json:"definition,omitempty"`
+	Fieldset   string      `json:"fieldset,omitempty"`
+	Children   []*Node     `json:"children,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. The tree is written as nested
+// Nodes, sorted by name, along with the initialisms and fieldsets of the schema.
+func (r *Root) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&rootJSON{
+		Initialisms: r.Initialisms,
+		Fieldsets:   r.Fieldsets,
+		Children:    r.sorted,
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It replaces the contents of the
+// Root with the tree read from data, which must be in the form written by MarshalJSON.
+func (r *Root) UnmarshalJSON(data []byte) error {
+	decoded := &rootJSON{}
+
+	err := json.Unmarshal(data, decoded)
+	if err != nil {
+		return err
+	}
+
+	*r = *NewRoot()
+	r.Initialisms = decoded.Initialisms
+
+	for name, fieldset := range decoded.Fieldsets {
+		r.Fieldsets[name] = fieldset
+	}
+
+	for _, child := range decoded.Children {
+		err := r.graft(child)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// graft adds a decoded Node and its descendants to the tree.
+func (r *Root) graft(decoded *Node) error {
+	node, err := r.Branch(decoded.Path)
+	if err != nil {
+		return err
+	}
+
+	node.Definition = decoded.Definition
+
+	if decoded.Fieldset != nil {
+		fieldset, found := r.Fieldsets[decoded.Fieldset.Name]
+		if !found {
+			return &SchemaError{Path: decoded.Path, Cause: fmt.Errorf("unknown fieldset %q", decoded.Fieldset.Name)}
+		}
+
+		node.Fieldset = fieldset
+	}
+
+	for _, child := range decoded.sorted {
+		err := r.graft(child)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The object and array values are
+// the results of IsObject and IsArray, so consumers do not have to reimplement them.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	encoded := &nodeJSON{
+		Name:       n.Name,
+		Path:       n.Path,
+		Object:     n.IsObject(),
+		Array:      n.IsArray(),
+		Definition: n.Definition,
+		Children:   n.sorted,
+	}
+
+	if n.Fieldset != nil {
+		encoded.Fieldset = n.Fieldset.Name
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The decoded Node is detached,
+// and its Fieldset only holds the fieldset name. Decode a whole Root to rebuild a tree.
+func (n *Node) UnmarshalJSON(data []byte) error {
+	decoded := &nodeJSON{}
+
+	err := json.Unmarshal(data, decoded)
+	if err != nil {
+		return err
+	}
+
+	*n = Node{
+		Name:       decoded.Name,
+		Path:       decoded.Path,
+		Children:   map[string]*Node{},
+		Definition: decoded.Definition,
+		sorted:     decoded.Children,
+	}
+
+	if decoded.Fieldset != "" {
+		n.Fieldset = &Fieldset{Name: decoded.Fieldset}
+	}
+
+	for _, child := range decoded.Children {
+		child.Parent = n
+		n.Children[child.Name] = child
+	}
+
+	return nil
+}
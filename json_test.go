@@ -0,0 +1,149 @@
+package ecsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// jsonRoot returns a tree with fieldsets, reused fields and an implied object.
+func jsonRoot(t *testing.T) *Root {
+	r := testRoot(t, map[string]*Definition{
+		"process.pid":         {FlatName: "process.pid", Type: "long", Level: LevelCore},
+		"process.args":        {FlatName: "process.args", Type: "keyword", Normalize: []string{"array"}},
+		"process.parent.pid":  {FlatName: "process.parent.pid", Type: "long", Level: LevelExtended},
+		"client.geo.location": {FlatName: "client.geo.location", Type: "geo_point", Example: "{ \"lon\": -73.614830, \"lat\": 45.505918 }"},
+		"client.nat.ip":       {FlatName: "client.nat.ip", Type: "ip", MultiFields: []*MultiField{{Name: "text", Type: "text"}}},
+	})
+
+	r.Fieldsets["process"] = &Fieldset{
+		Name:     "process",
+		Title:    "Process",
+		Reusable: &Reusable{Expected: []*ReuseLocation{{At: "process", As: "parent"}}},
+	}
+	r.Fieldsets["geo"] = &Fieldset{Name: "geo", Reusable: &Reusable{Expected: []*ReuseLocation{{At: "client"}}}}
+
+	for id, fieldset := range map[string]string{"process": "process", "process.parent": "process", "client.geo": "geo"} {
+		node, _ := r.Lookup(id)
+		node.Fieldset = r.Fieldsets[fieldset]
+	}
+
+	return r
+}
+
+func TestRootJSONRoundTrip(t *testing.T) {
+	r := jsonRoot(t)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	decoded := NewRoot()
+	err = json.Unmarshal(data, decoded)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("Marshal() of the decoded Root error = %v", err)
+	}
+
+	if !bytes.Equal(data, again) {
+		t.Errorf("round trip changed the JSON:\n%s\n%s", data, again)
+	}
+
+	if !reflect.DeepEqual(decoded.Fieldsets, r.Fieldsets) {
+		t.Errorf("round trip changed the fieldsets")
+	}
+
+	if len(decoded.Index) != len(r.Index) {
+		t.Fatalf("round trip has %d Nodes, want %d", len(decoded.Index), len(r.Index))
+	}
+
+	for p, want := range r.Index {
+		got, found := decoded.Lookup(p)
+		if !found {
+			t.Errorf("round trip lost %s", p)
+			continue
+		}
+
+		if !reflect.DeepEqual(got.Definition, want.Definition) {
+			t.Errorf("%s definition = %+v, want %+v", p, got.Definition, want.Definition)
+		}
+
+		if got.Root != decoded || (want.Parent != nil && got.Parent != decoded.Index[want.Parent.Path]) {
+			t.Errorf("%s is not linked into the decoded tree", p)
+		}
+
+		// fieldsets are shared with the decoded Root, not copies of their own
+		if want.Fieldset != nil && got.Fieldset != decoded.Fieldsets[want.Fieldset.Name] {
+			t.Errorf("%s fieldset is not linked to the decoded Root", p)
+		}
+
+		if got.IsObject() != want.IsObject() || got.IsArray() != want.IsArray() {
+			t.Errorf("%s object or array changed", p)
+		}
+	}
+}
+
+func TestNodeMarshalJSON(t *testing.T) {
+	r := jsonRoot(t)
+
+	tests := []struct {
+		path         string
+		wantObject   bool
+		wantArray    bool
+		wantFieldset string
+		wantChildren int
+	}{
+		{path: "process", wantObject: true, wantFieldset: "process", wantChildren: 3},
+		{path: "process.args", wantArray: true},
+		{path: "client.nat", wantObject: true, wantChildren: 1},
+		{path: "client.geo", wantObject: true, wantFieldset: "geo", wantChildren: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node, _ := r.Lookup(tt.path)
+
+			data, err := json.Marshal(node)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			got := &nodeJSON{}
+			err = json.Unmarshal(data, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Path != tt.path || got.Object != tt.wantObject || got.Array != tt.wantArray ||
+				got.Fieldset != tt.wantFieldset || len(got.Children) != tt.wantChildren {
+				t.Errorf("Marshal() = %s", data)
+			}
+		})
+	}
+}
+
+func TestRootUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "invalid json", data: `{"children": [`},
+		{name: "unknown fieldset", data: `{"children": [{"name": "process", "path": "process", "fieldset": "process"}]}`},
+		{name: "invalid path", data: `{"children": [{"name": "process", "path": "process..pid"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tt.data), NewRoot())
+			if err == nil {
+				t.Errorf("Unmarshal(%s) did not fail", tt.data)
+			}
+		})
+	}
+}
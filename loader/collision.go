@@ -0,0 +1,99 @@
+package loader
+
+import (
+	"sort"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// collision describes a scalar field that is also used as the parent object of other fields.
+type collision struct {
+	// field is the ECS key of the scalar field.
+	field string
+
+	// child is the ECS key of the first field, in sorted order, that uses field as its parent object.
+	child string
+
+	// movedTo is where the collision strategy moved the scalar field. It is empty if the
+	// field was not moved, in which case the collision is an error.
+	movedTo string
+
+	// blocked is set when the field could not be moved, because a field already exists at the new path.
+	blocked bool
+}
+
+// collisions returns every scalar field within defs that is also used as the parent object of
+// another field in defs, sorted by ECS key. Object and nested fields hold other fields, so
+// they never collide.
+func collisions(defs map[string]*ecsgen.Definition) []*collision {
+	found := map[string]*collision{}
+
+	for _, id := range sortedKeys(defs) {
+		for parent := parentPath(id); parent != ""; parent = parentPath(parent) {
+			pdef, ok := defs[parent]
+			if !ok || isObjectType(pdef.Type) {
+				continue
+			}
+
+			if _, ok := found[parent]; !ok {
+				found[parent] = &collision{field: parent, child: id}
+			}
+		}
+	}
+
+	ret := make([]*collision, 0, len(found))
+	for _, c := range found {
+		ret = append(ret, c)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].field < ret[j].field
+	})
+
+	return ret
+}
+
+// resolveCollisions applies the configured collision strategy to the definitions that will be
+// added to the tree, returning the definitions keyed by the path they should be placed at, along
+// with every collision that was found. With the suffix strategy, the object is kept and the scalar
+// field is moved to a sibling path with the suffix appended (i.e. "foo.bar" => "foo.bar_value").
+// Collisions that were not moved are returned by unresolvedCollisions, and fail loading.
+func (l *Loader) resolveCollisions(defs map[string]*ecsgen.Definition) (map[string]*ecsgen.Definition, []*collision) {
+	found := collisions(defs)
+
+	if l.config.CollisionStrategy != config.CollisionStrategySuffix {
+		return defs, found
+	}
+
+	for _, c := range found {
+		renamed := c.field + l.config.CollisionSuffix
+		if _, exists := defs[renamed]; exists {
+			c.blocked = true
+			continue
+		}
+
+		defs[renamed] = defs[c.field]
+		delete(defs, c.field)
+		c.movedTo = renamed
+	}
+
+	return defs, found
+}
+
+// unresolvedCollisions returns an error Diagnostic for every collision the collision strategy did
+// not move out of the way, which is every collision when using the error strategy.
+func (l *Loader) unresolvedCollisions() Diagnostics {
+	v := &validator{
+		schema: l.schema,
+		diags:  Diagnostics{},
+	}
+
+	for _, c := range l.collisions {
+		if c.movedTo == "" {
+			v.collision(c)
+		}
+	}
+
+	return v.diags
+}
@@ -0,0 +1,102 @@
+package loader
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// collisionSchema has a scalar field that is the parent of another field, and a nested field
+// that holds other fields.
+const collisionSchema = `
+foo.bar: {flat_name: foo.bar, name: bar, type: keyword, level: custom}
+foo.bar.baz: {flat_name: foo.bar.baz, name: bar.baz, type: long, level: custom}
+foo.items: {flat_name: foo.items, name: items, type: nested, level: custom}
+foo.items.id: {flat_name: foo.items.id, name: items.id, type: keyword, level: custom}
+`
+
+// blockedSchema adds a colliding scalar field whose suffixed name is already taken.
+const blockedSchema = collisionSchema + `qux.id: {flat_name: qux.id, name: id, type: keyword, level: custom}
+qux.id.name: {flat_name: qux.id.name, name: id.name, type: keyword, level: custom}
+qux.id_value: {flat_name: qux.id_value, name: id_value, type: keyword, level: custom}
+`
+
+func TestLoadCollisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		schema    string
+		strategy  string
+		wantErrs  []string
+		wantWarns []string
+		want      []string
+	}{
+		{
+			name:     "error strategy fails loading",
+			schema:   collisionSchema,
+			strategy: config.CollisionStrategyError,
+			wantErrs: []string{
+				"ecs_flat.yml:2: error: foo.bar: keyword field is also used as the parent object of foo.bar.baz",
+			},
+		},
+		{
+			name:     "suffix strategy moves the scalar field",
+			schema:   collisionSchema,
+			strategy: config.CollisionStrategySuffix,
+			wantWarns: []string{
+				"ecs_flat.yml:2: warning: foo.bar: keyword field is also used as the parent object of foo.bar.baz, and will be moved to foo.bar_value",
+			},
+			want: []string{"foo.bar.baz", "foo.bar_value", "foo.items", "foo.items.id"},
+		},
+		{
+			name:     "suffix strategy fails loading when the suffixed name is taken",
+			schema:   blockedSchema,
+			strategy: config.CollisionStrategySuffix,
+			wantErrs: []string{
+				"ecs_flat.yml:6: error: qux.id: keyword field is also used as the parent object of qux.id.name, and cannot be moved: a field with the suffixed name already exists",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := loadTestSchema(t, tt.schema, config.Options{CollisionStrategy: tt.strategy})
+
+			if len(tt.wantErrs) > 0 {
+				var diags Diagnostics
+				if !errors.As(err, &diags) {
+					t.Fatalf("Load() error = %v, want Diagnostics", err)
+				}
+
+				if got := diagStrings(diags); !reflect.DeepEqual(got, tt.wantErrs) {
+					t.Errorf("Load() error = %q, want %q", got, tt.wantErrs)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if got := fields(l); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() fields = %v, want %v", got, tt.want)
+			}
+
+			if got := diagStrings(l.Validate()); !reflect.DeepEqual(got, tt.wantWarns) {
+				t.Errorf("Validate() = %q, want %q", got, tt.wantWarns)
+			}
+		})
+	}
+}
+
+// diagStrings returns the String of each Diagnostic.
+func diagStrings(diags Diagnostics) []string {
+	ret := []string{}
+	for _, d := range diags {
+		ret = append(ret, d.String())
+	}
+
+	return ret
+}
@@ -0,0 +1,190 @@
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-ucfg/yaml"
+	"github.com/gen0cide/ecsgen"
+)
+
+const (
+	// beatsFieldsFilename is the conventional name of a Beats field definition file.
+	beatsFieldsFilename = "fields.yml"
+
+	// beatsDefaultType is the type Beats assigns to fields that do not specify one.
+	beatsDefaultType = "keyword"
+
+	// beatsDefaultLevel is the level given to Beats fields, as they are not part of ECS.
+	beatsDefaultLevel = "custom"
+)
+
+// beatsField represents a single entry within a Beats "fields.yml" file. Top level entries
+// use Key to group fields by module, while nested entries use Name and are either a
+// "group" with child Fields, or a leaf field.
+// nolint:maligned
+type beatsField struct {
+	Key         string               `config:"key"`
+	Name        string               `config:"name"`
+	Type        string               `config:"type"`
+	Level       string               `config:"level"`
+	Description string               `config:"description"`
+	Example     interface{}          `config:"example"`
+	Format      string               `config:"format"`
+	IgnoreAbove int                  `config:"ignore_above"`
+	Normalize   []string             `config:"normalize"`
+	MultiFields []*ecsgen.MultiField `config:"multi_fields"`
+	Fields      []*beatsField        `config:"fields"`
+}
+
+// readBeatsFields parses a Beats "fields.yml" file, or every "fields.yml" file found beneath a
+// directory, into the same shape that readFlat returns. Groups become implied objects and every
+// other entry becomes a field Definition. Alias fields are skipped, as they only point at other fields.
+func readBeatsFields(fsys fs.FS, path string) (*sourceData, error) {
+	files, err := beatsFieldsFiles(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &sourceData{
+		definitions: map[string]*ecsgen.Definition{},
+		positions:   positions{},
+	}
+
+	for _, file := range files {
+		var entries []*beatsField
+
+		contents, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading Beats YAML %s: %v", file, err)
+		}
+
+		config, err := yaml.NewConfig(contents)
+		if err != nil {
+			return nil, fmt.Errorf("error reading Beats YAML %s: %v", file, err)
+		}
+
+		err = config.Unpack(&entries)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling YAML %s into beats fields: %v", file, err)
+		}
+
+		for _, entry := range entries {
+			// entries with a key are module groupings, and their
+			// fields are relative to the top level namespace
+			if entry.Key != "" {
+				err = flattenBeatsFields(ret.definitions, "", entry.Fields)
+			} else {
+				err = flattenBeatsFields(ret.definitions, "", []*beatsField{entry})
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("error in %s: %v", file, err)
+			}
+		}
+
+		for key, pos := range beatsPositions(file, contents) {
+			ret.positions[key] = pos
+		}
+	}
+
+	return ret, nil
+}
+
+// beatsFieldsFiles resolves the list of files to read for a Beats fields source. A file is
+// returned as is, while a directory is walked for every file named "fields.yml".
+func beatsFieldsFiles(fsys fs.FS, path string) ([]string, error) {
+	info, err := fs.Stat(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("error locating Beats fields: %v", err)
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+
+	err = fs.WalkDir(fsys, path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && d.Name() == beatsFieldsFilename {
+			files = append(files, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking Beats fields directory: %v", err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", beatsFieldsFilename, path)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// flattenBeatsFields recursively adds the definitions of fields to data, using prefix as the
+// path of the group they belong to.
+func flattenBeatsFields(data map[string]*ecsgen.Definition, prefix string, fields []*beatsField) error {
+	for _, field := range fields {
+		if field.Name == "" {
+			return fmt.Errorf("field without a name in group %q", strings.TrimSuffix(prefix, "."))
+		}
+
+		flatName := prefix + field.Name
+
+		switch field.Type {
+		case "group":
+			err := flattenBeatsFields(data, flatName+".", field.Fields)
+			if err != nil {
+				return err
+			}
+
+			continue
+		case "alias":
+			continue
+		}
+
+		if _, found := data[flatName]; found {
+			return fmt.Errorf("field %s is defined more than once", flatName)
+		}
+
+		def := &ecsgen.Definition{
+			Name:        field.Name,
+			FlatName:    flatName,
+			DashedName:  dashedName(flatName),
+			Type:        field.Type,
+			Level:       field.Level,
+			Description: field.Description,
+			Example:     field.Example,
+			Format:      field.Format,
+			IgnoreAbove: field.IgnoreAbove,
+			Normalize:   field.Normalize,
+			MultiFields: field.MultiFields,
+		}
+
+		if def.Type == "" {
+			def.Type = beatsDefaultType
+		}
+
+		if def.Level == "" {
+			def.Level = beatsDefaultLevel
+		}
+
+		for _, mf := range def.MultiFields {
+			mf.FlatName = flatName + "." + mf.Name
+		}
+
+		data[flatName] = def
+	}
+
+	return nil
+}
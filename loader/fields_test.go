@@ -0,0 +1,159 @@
+package loader
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// beatsFiles is a directory of Beats field definitions, with a module grouped by key, nested
+// groups, key-less entries, fields that set their own type and level, and an alias.
+var beatsFiles = fstest.MapFS{
+	"module/nginx/fields.yml": {Data: []byte(`
+- key: nginx
+  title: Nginx
+  fields:
+    - name: nginx
+      type: group
+      fields:
+        - name: access
+          type: group
+          fields:
+            - name: remote_ip
+              type: ip
+              level: extended
+              description: Client IP address.
+            - name: agent
+              ignore_above: 256
+              multi_fields:
+                - {name: text, type: text}
+            - name: source
+              type: alias
+              path: nginx.access.remote_ip
+`)},
+	"module/service/fields.yml": {Data: []byte(`
+- name: service
+  type: group
+  fields:
+    - {name: latency, type: long, format: duration}
+- name: tag
+`)},
+	"module/service/README.md": {Data: []byte("not a fields file")},
+}
+
+func TestLoadBeatsFields(t *testing.T) {
+	l, err := loadTestSource(t, config.Source{
+		Path:   "module",
+		Format: config.SourceFormatFields,
+		FS:     beatsFiles,
+	}, config.Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// groups are objects, and aliases are skipped
+	want := []string{"nginx.access.agent", "nginx.access.remote_ip", "service.latency", "tag"}
+	if got := fields(l); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load() fields = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		path string
+		want *ecsgen.Definition
+	}{
+		{
+			path: "nginx.access.remote_ip",
+			want: &ecsgen.Definition{
+				ID:          "nginx.access.remote_ip",
+				Name:        "remote_ip",
+				FlatName:    "nginx.access.remote_ip",
+				DashedName:  "nginx-access-remote-ip",
+				Type:        "ip",
+				Level:       "extended",
+				Description: "Client IP address.",
+			},
+		},
+		{
+			path: "nginx.access.agent",
+			want: &ecsgen.Definition{
+				ID:          "nginx.access.agent",
+				Name:        "agent",
+				FlatName:    "nginx.access.agent",
+				DashedName:  "nginx-access-agent",
+				Type:        beatsDefaultType,
+				Level:       beatsDefaultLevel,
+				IgnoreAbove: 256,
+				MultiFields: []*ecsgen.MultiField{{Name: "text", Type: "text", FlatName: "nginx.access.agent.text"}},
+			},
+		},
+		{
+			path: "service.latency",
+			want: &ecsgen.Definition{
+				ID:         "service.latency",
+				Name:       "latency",
+				FlatName:   "service.latency",
+				DashedName: "service-latency",
+				Type:       "long",
+				Level:      beatsDefaultLevel,
+				Format:     "duration",
+			},
+		},
+		{
+			path: "tag",
+			want: &ecsgen.Definition{
+				ID:         "tag",
+				Name:       "tag",
+				FlatName:   "tag",
+				DashedName: "tag",
+				Type:       beatsDefaultType,
+				Level:      beatsDefaultLevel,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node, _ := l.Root().Lookup(tt.path)
+			if !reflect.DeepEqual(node.Definition, tt.want) {
+				t.Errorf("%s = %+v, want %+v", tt.path, node.Definition, tt.want)
+			}
+		})
+	}
+
+	// nested fields point at the line of their own entry
+	if pos := l.schema.position("nginx.access.remote_ip"); pos.String() != "module/nginx/fields.yml:11" {
+		t.Errorf("position = %s, want module/nginx/fields.yml:11", pos)
+	}
+}
+
+func TestReadBeatsFieldsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		wantErr  string
+	}{
+		{
+			name:     "field without a name",
+			contents: "- name: service\n  type: group\n  fields:\n    - {type: long}\n",
+			wantErr:  `field without a name in group "service"`,
+		},
+		{
+			name:     "field defined twice",
+			contents: "- name: tag\n- name: tag\n",
+			wantErr:  "field tag is defined more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readBeatsFields(fstest.MapFS{"fields.yml": {Data: []byte(tt.contents)}}, "fields.yml")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readBeatsFields() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package loader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// baseFieldset is the name of the ECS fieldset that holds the top level fields, such as "@timestamp".
+const baseFieldset = "base"
+
+// FilterDecision records whether a single definition was kept or dropped by the filters, and why.
+type FilterDecision struct {
+	Field  string
+	Kept   bool
+	Reason string
+}
+
+// String implements the fmt.Stringer interface.
+func (d *FilterDecision) String() string {
+	status := "dropped"
+	if d.Kept {
+		status = "kept"
+	}
+
+	return fmt.Sprintf("%s: %s: %s", status, d.Field, d.Reason)
+}
+
+// FilterReport explains the result of filtering the loaded definitions.
+type FilterReport struct {
+	// Decisions holds a FilterDecision for every loaded definition, sorted by ECS key.
+	Decisions []*FilterDecision
+
+	// Unused describes each filter that did not match any loaded definition,
+	// such as a whitelist rule with a typo.
+	Unused []string
+}
+
+// FilterReport returns the report of the filters applied by Load. It is nil until Load has been called.
+func (l *Loader) FilterReport() *FilterReport {
+	return l.report
+}
+
+// selectDefinitions returns the definitions of a schema that pass the whitelist, blacklist,
+// fieldset and level filters, along with a report of every decision. The object definitions
+// that are ancestors of a selected field are always selected as well, so a deep field keeps
+// the shape of the objects it belongs to.
+func (l *Loader) selectDefinitions(s *schema) (map[string]*ecsgen.Definition, *FilterReport, error) {
+	whitelist, err := l.config.Whitelist()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating ecs key whitelist: %v", err)
+	}
+
+	blacklist, err := l.config.Blacklist()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating ecs key blacklist: %v", err)
+	}
+
+	levels := toSet(l.config.Levels())
+	include := toSet(l.config.IncludeFieldsets())
+	exclude := toSet(l.config.ExcludeFieldsets())
+	fieldsets := fieldsetIndex(s.fieldsets)
+
+	// track which rules and fieldsets matched anything, so unused ones can be reported
+	usedWhitelist := map[string]bool{}
+	usedBlacklist := map[string]bool{}
+	usedFieldsets := map[string]bool{}
+
+	selected := map[string]*ecsgen.Definition{}
+	decisions := map[string]*FilterDecision{}
+
+	// enumerate the parsed map and select the definitions that pass the filters
+	for id, def := range s.definitions {
+		whitelisted := whitelist.Matches(id, def)
+		blacklisted := blacklist.Matches(id, def)
+		fieldset := fieldsetOf(id, fieldsets)
+		level := levelOf(def)
+
+		for _, rule := range whitelisted {
+			usedWhitelist[rule.String()] = true
+		}
+
+		for _, rule := range blacklisted {
+			usedBlacklist[rule.String()] = true
+		}
+
+		usedFieldsets[fieldset] = true
+
+		decision := &FilterDecision{Field: id}
+		decisions[id] = decision
+
+		switch {
+		// if whitelist is empty, all values will pass
+		// if not, only specific values will pass
+		case !whitelist.Empty() && len(whitelisted) == 0:
+			decision.Reason = "not matched by any whitelist rule"
+		// if the blacklist has elements *and* they match
+		// the id, skip to the next field
+		case len(blacklisted) > 0:
+			decision.Reason = fmt.Sprintf("blacklisted by %q", blacklisted[0])
+		// if fieldsets were included, only their fields pass.
+		// excluded fieldsets never pass.
+		case len(include) > 0 && !include[fieldset]:
+			decision.Reason = fmt.Sprintf("fieldset %s is not included", fieldset)
+		case exclude[fieldset]:
+			decision.Reason = fmt.Sprintf("fieldset %s is excluded", fieldset)
+		// if levels were given, only fields at those levels pass.
+		// fields without a level are not part of ECS, so they are custom.
+		case len(levels) > 0 && !levels[level]:
+			decision.Reason = fmt.Sprintf("level %s is not allowed", level)
+		default:
+			decision.Kept = true
+			decision.Reason = keptReason(whitelisted, fieldset, include, level, levels)
+			selected[id] = def
+		}
+	}
+
+	// keep the object definitions above every selected field (i.e. "dns.answers"
+	// for "dns.answers.data"), otherwise the object would lose its definition
+	// and become implied.
+	for _, id := range sortedKeys(selected) {
+		for parent := parentPath(id); parent != ""; parent = parentPath(parent) {
+			def, found := s.definitions[parent]
+			if !found || !isObjectType(def.Type) || decisions[parent].Kept {
+				continue
+			}
+
+			selected[parent] = def
+			decisions[parent].Kept = true
+			decisions[parent].Reason = fmt.Sprintf("parent object of %s (otherwise %s)", id, decisions[parent].Reason)
+		}
+	}
+
+	report := &FilterReport{
+		Decisions: []*FilterDecision{},
+		Unused:    []string{},
+	}
+
+	for _, id := range sortedKeys(s.definitions) {
+		report.Decisions = append(report.Decisions, decisions[id])
+	}
+
+	for _, rule := range whitelist {
+		if !usedWhitelist[rule.String()] {
+			report.Unused = append(report.Unused, fmt.Sprintf("whitelist rule %q", rule))
+		}
+	}
+
+	for _, rule := range blacklist {
+		if !usedBlacklist[rule.String()] {
+			report.Unused = append(report.Unused, fmt.Sprintf("blacklist rule %q", rule))
+		}
+	}
+
+	for _, name := range l.config.IncludeFieldsets() {
+		if !usedFieldsets[name] {
+			report.Unused = append(report.Unused, fmt.Sprintf("included fieldset %s", name))
+		}
+	}
+
+	for _, name := range l.config.ExcludeFieldsets() {
+		if !usedFieldsets[name] {
+			report.Unused = append(report.Unused, fmt.Sprintf("excluded fieldset %s", name))
+		}
+	}
+
+	return selected, report, nil
+}
+
+// keptReason describes the filters a kept definition passed.
+func keptReason(whitelisted []config.FilterRule, fieldset string, include map[string]bool, level string, levels map[string]bool) string {
+	reasons := []string{}
+
+	if len(whitelisted) > 0 {
+		reasons = append(reasons, fmt.Sprintf("whitelisted by %q", whitelisted[0]))
+	}
+
+	if len(include) > 0 {
+		reasons = append(reasons, fmt.Sprintf("fieldset %s is included", fieldset))
+	}
+
+	if len(levels) > 0 {
+		reasons = append(reasons, fmt.Sprintf("level %s is allowed", level))
+	}
+
+	if len(reasons) == 0 {
+		return "no filter applies"
+	}
+
+	return strings.Join(reasons, ", ")
+}
+
+// fieldsetIndex maps the ECS key of every field that belongs to a fieldset to the name
+// of the fieldset.
+func fieldsetIndex(fieldsets map[string]*ecsgen.Fieldset) map[string]string {
+	ret := map[string]string{}
+
+	for name, fieldset := range fieldsets {
+		if !fieldset.IsTopLevel() {
+			continue
+		}
+
+		for key, def := range fieldset.Fields {
+			flatName := def.FlatName
+			if flatName == "" {
+				flatName = fieldset.Prefix + key
+			}
+
+			ret[flatName] = name
+		}
+	}
+
+	return ret
+}
+
+// fieldsetOf returns the name of the fieldset an ECS key belongs to. Sources without fieldset
+// metadata (such as "ecs_flat.yml") fall back to the ECS naming rules: the first element of
+// the key is the fieldset, and keys without a "." belong to the base fieldset.
+func fieldsetOf(id string, index map[string]string) string {
+	if name, found := index[id]; found {
+		return name
+	}
+
+	if idx := strings.Index(id, "."); idx >= 0 {
+		return id[:idx]
+	}
+
+	return baseFieldset
+}
+
+// isObjectType returns true if a field of the given type holds other fields.
+func isObjectType(fieldType string) bool {
+	return fieldType == "object" || fieldType == "nested"
+}
+
+// levelOf returns the ECS level of a definition, which is custom if no level was given.
+func levelOf(def *ecsgen.Definition) string {
+	if def.Level == "" {
+		return ecsgen.LevelCustom
+	}
+
+	return def.Level
+}
+
+// toSet converts a list of values into a set.
+func toSet(values []string) map[string]bool {
+	ret := map[string]bool{}
+	for _, v := range values {
+		ret[v] = true
+	}
+
+	return ret
+}
+
+// sortedKeys returns the keys of a definition map in sorted order.
+func sortedKeys(defs map[string]*ecsgen.Definition) []string {
+	ret := make([]string, 0, len(defs))
+	for id := range defs {
+		ret = append(ret, id)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
@@ -0,0 +1,202 @@
+package loader
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// filterSchema is a small flat schema with fields at every level, in a few fieldsets.
+const filterSchema = `
+dns.question.name: {flat_name: dns.question.name, name: question.name, type: keyword, level: extended}
+dns.type: {flat_name: dns.type, name: type, type: keyword, level: core}
+process.pid: {flat_name: process.pid, name: pid, type: long, level: core}
+process.name: {flat_name: process.name, name: name, type: keyword, level: extended}
+process.parent.pid: {flat_name: process.parent.pid, name: parent.pid, type: long, level: extended}
+process.custom: {flat_name: process.custom, name: custom, type: keyword}
+threat.enrichments: {flat_name: threat.enrichments, name: enrichments, type: nested, level: extended}
+threat.enrichments.indicator.ip: {flat_name: threat.enrichments.indicator.ip, name: enrichments.indicator.ip, type: ip, level: extended}
+`
+
+// newTestLoader loads a flat schema from memory with the given options, failing the test
+// if it cannot be loaded.
+func newTestLoader(t *testing.T, contents string, opts config.Options) *Loader {
+	t.Helper()
+
+	l, err := loadTestSchema(t, contents, opts)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	return l
+}
+
+// loadTestSchema loads a flat schema from memory with the given options, returning the
+// error of Load.
+func loadTestSchema(t *testing.T, contents string, opts config.Options) (*Loader, error) {
+	t.Helper()
+
+	return loadTestSource(t, config.Source{
+		Path:   "ecs_flat.yml",
+		Format: config.SourceFormatFlat,
+		FS:     fstest.MapFS{"ecs_flat.yml": {Data: []byte(contents)}},
+	}, opts)
+}
+
+// loadTestSource loads a single source with the given options, returning the error of Load.
+func loadTestSource(t *testing.T, source config.Source, opts config.Options) (*Loader, error) {
+	t.Helper()
+
+	opts.Sources = []config.Source{source}
+
+	c, err := config.New(opts)
+	if err != nil {
+		t.Fatalf("config.New() error = %v", err)
+	}
+
+	l, err := NewLoader(c)
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+
+	return l, l.Load()
+}
+
+// fields returns the sorted paths of the Nodes in a Loader's tree that have a Definition.
+func fields(l *Loader) []string {
+	ret := []string{}
+	for p, node := range l.Root().Index {
+		if node.Definition != nil {
+			ret = append(ret, p)
+		}
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+func TestSelectDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters config.Filters
+		want    []string
+	}{
+		{
+			name: "no filters keep everything",
+			want: []string{
+				"dns.question.name", "dns.type", "process.custom", "process.name", "process.parent.pid", "process.pid",
+				"threat.enrichments", "threat.enrichments.indicator.ip",
+			},
+		},
+		{
+			name:    "whitelist",
+			filters: config.Filters{Whitelist: []string{"glob:process.*"}},
+			want:    []string{"process.custom", "process.name", "process.pid"},
+		},
+		{
+			name:    "blacklist wins over whitelist",
+			filters: config.Filters{Whitelist: []string{"glob:process.**"}, Blacklist: []string{`^process\.parent`}},
+			want:    []string{"process.custom", "process.name", "process.pid"},
+		},
+		{
+			name:    "included fieldsets",
+			filters: config.Filters{IncludeFieldsets: []string{"dns"}},
+			want:    []string{"dns.question.name", "dns.type"},
+		},
+		{
+			name:    "excluded fieldset wins over included fieldset",
+			filters: config.Filters{IncludeFieldsets: []string{"dns", "process"}, ExcludeFieldsets: []string{"process"}},
+			want:    []string{"dns.question.name", "dns.type"},
+		},
+		{
+			name:    "whitelist and included fieldsets must both pass",
+			filters: config.Filters{Whitelist: []string{"glob:**.pid"}, IncludeFieldsets: []string{"dns"}},
+			want:    []string{},
+		},
+		{
+			name:    "levels",
+			filters: config.Filters{Levels: []string{"core"}},
+			want:    []string{"dns.type", "process.pid"},
+		},
+		{
+			name:    "fields without a level are custom",
+			filters: config.Filters{Levels: []string{"custom"}},
+			want:    []string{"process.custom"},
+		},
+		{
+			name:    "levels apply after the other filters",
+			filters: config.Filters{IncludeFieldsets: []string{"process"}, Levels: []string{"extended"}},
+			want:    []string{"process.name", "process.parent.pid"},
+		},
+		{
+			name:    "ancestor objects of selected fields are kept",
+			filters: config.Filters{Whitelist: []string{"glob:**.ip"}},
+			want:    []string{"threat.enrichments", "threat.enrichments.indicator.ip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLoader(t, filterSchema, config.Options{Filters: tt.filters})
+
+			if got := fields(l); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+
+			// implied objects only exist above the selected fields
+			for p, node := range l.Root().Index {
+				if node.Definition == nil && len(node.Children) == 0 {
+					t.Errorf("empty implied object %s was not pruned", p)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterReport(t *testing.T) {
+	l := newTestLoader(t, filterSchema, config.Options{
+		Filters: config.Filters{
+			Whitelist:        []string{"glob:process.*", "glob:typo.*"},
+			Blacklist:        []string{"glob:process.custom"},
+			ExcludeFieldsets: []string{"nothing"},
+		},
+	})
+
+	report := l.FilterReport()
+
+	decisions := map[string]*FilterDecision{}
+	for _, d := range report.Decisions {
+		decisions[d.Field] = d
+	}
+
+	tests := []struct {
+		field  string
+		kept   bool
+		reason string
+	}{
+		{field: "process.pid", kept: true, reason: `whitelisted by "glob:process.*"`},
+		{field: "process.custom", kept: false, reason: `blacklisted by "glob:process.custom"`},
+		{field: "dns.type", kept: false, reason: "not matched by any whitelist rule"},
+	}
+
+	for _, tt := range tests {
+		d, found := decisions[tt.field]
+		if !found {
+			t.Errorf("no decision for %s", tt.field)
+			continue
+		}
+
+		if d.Kept != tt.kept || d.Reason != tt.reason {
+			t.Errorf("decision for %s = %v, want kept=%v reason=%q", tt.field, d, tt.kept, tt.reason)
+		}
+	}
+
+	wantUnused := []string{`whitelist rule "glob:typo.*"`, "excluded fieldset nothing"}
+	if !reflect.DeepEqual(report.Unused, wantUnused) {
+		t.Errorf("unused = %v, want %v", report.Unused, wantUnused)
+	}
+}
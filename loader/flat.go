@@ -0,0 +1,25 @@
+package loader
+
+import (
+	"fmt"
+
+	"github.com/elastic/go-ucfg/yaml"
+	"github.com/gen0cide/ecsgen"
+)
+
+// readFlat parses the contents of an ECS generated "ecs_flat.yml" file into a map of definitions keyed by ECS key.
+func readFlat(contents []byte) (map[string]*ecsgen.Definition, error) {
+	var data map[string]*ecsgen.Definition
+
+	config, err := yaml.NewConfig(contents)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ECS YAML: %v", err)
+	}
+
+	err = config.Unpack(&data)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling YAML into ecsgen definitions: %v", err)
+	}
+
+	return data, nil
+}
@@ -3,8 +3,9 @@ package loader
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"sort"
 
-	"github.com/elastic/go-ucfg/yaml"
 	"github.com/gen0cide/ecsgen"
 	"github.com/gen0cide/ecsgen/config"
 )
@@ -13,6 +14,16 @@ import (
 type Loader struct {
 	config *config.Config
 	root   *ecsgen.Root
+	schema *schema
+	report *FilterReport
+
+	// selected holds the sorted keys of the definitions that passed the filters, which are
+	// the only ones checked by Validate
+	selected []string
+
+	// collisions found while building the tree. moved fields are reported by Validate,
+	// while any others fail Load
+	collisions []*collision
 }
 
 // NewLoader is used to create a new Loader for ECS schema definition parsing.
@@ -32,52 +43,144 @@ func NewLoader(c *config.Config) (*Loader, error) {
 	}, nil
 }
 
-// Load attempts to load the YAML configuration into an ecsgen definition tree.
+// Load attempts to load the YAML configuration into an ecsgen definition tree. Every source
+// returned by the config is read in order and merged into a single schema before the tree is built.
+// If scalar fields are also used as objects, and the collision strategy does not move them, the
+// returned error is the Diagnostics of every one of them.
 func (l *Loader) Load() error {
-	var data map[string]*ecsgen.Definition
+	merged := newSchema()
 
-	config, err := yaml.NewConfigWithFile(l.config.SourceFile)
-	if err != nil {
-		return fmt.Errorf("error reading ECS YAML: %v", err)
+	for _, source := range l.config.Sources() {
+		data, err := readSource(source)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %w", source, err)
+		}
+
+		err = merged.merge(source.String(), data, l.config.AllowOverrides)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = config.Unpack(&data)
+	// replace the types of overridden fields before anything looks at them
+	overrides, err := l.config.TypeOverrides()
 	if err != nil {
-		return fmt.Errorf("error marshaling YAML into ecsgen definitions: %v", err)
+		return err
 	}
 
-	whitelist, err := l.config.Whitelist()
-	if err != nil {
-		return fmt.Errorf("error creating ecs key whitelist: %v", err)
+	for id, fieldType := range overrides {
+		def, found := merged.definitions[id]
+		if !found {
+			return fmt.Errorf("cannot override the type of %s: field is not defined", id)
+		}
+
+		def.Type = fieldType
 	}
 
-	blacklist, err := l.config.Blacklist()
+	l.schema = merged
+
+	// select the definitions that pass the filters
+	selected, report, err := l.selectDefinitions(merged)
 	if err != nil {
-		return fmt.Errorf("error creating ecs key blacklist: %v", err)
+		return err
 	}
 
-	// enumerate the parsed map and create the structure
-	for id, def := range data {
-		// if whitelist is empty, all values will pass
-		// if not, only specific values will pass
-		if !whitelist.Empty() && !whitelist.Match(id) {
-			continue
-		}
+	l.report = report
 
-		// if the blacklist has elements *and* they match
-		// the id, skip to the next field
-		if !blacklist.Empty() && blacklist.Match(id) {
-			continue
-		}
+	l.selected = []string{}
+	for id := range selected {
+		l.selected = append(l.selected, id)
+	}
+
+	sort.Strings(l.selected)
+
+	// apply the collision strategy to scalar fields that are also objects. a field cannot
+	// be both in the tree, so any collisions that remain fail loading.
+	selected, l.collisions = l.resolveCollisions(selected)
+
+	unresolved := l.unresolvedCollisions()
+	if len(unresolved) > 0 {
+		return unresolved
+	}
 
+	// create the structure. objects are only created as the parents of selected
+	// definitions, so implied objects of filtered fields never make it into the tree.
+	for id, def := range selected {
 		def.ID = id
-		node := l.root.Branch(id)
+
+		node, err := l.root.Branch(id)
+		if err != nil {
+			return fmt.Errorf("%s: error adding %s to the schema tree: %w", l.schema.position(id), id, err)
+		}
+
 		node.Definition = def
 	}
 
+	// link the fieldset metadata to the objects that made it into the tree
+	linkFieldsets(l.root, merged.fieldsets)
+
+	// identifiers are created by the output plugins from the tree, so the initialisms
+	// travel with it instead of being registered for the whole process
+	l.root.Initialisms = l.config.Initialisms()
+
 	return nil
 }
 
+// readSource reads the definitions, and fieldsets if the format carries them, from a single source.
+func readSource(source config.Source) (*sourceData, error) {
+	// directory based formats are read by their own loaders
+	switch source.Format {
+	case config.SourceFormatSchemas:
+		return readSchemas(source.FileSystem(), source.Path)
+	case config.SourceFormatFields:
+		return readBeatsFields(source.FileSystem(), source.Path)
+	}
+
+	contents, err := readSourceFile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	switch source.Format {
+	case config.SourceFormatNested:
+		fieldsets, err := readNested(contents)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := flattenFieldsets(fieldsets)
+		if err != nil {
+			return nil, fmt.Errorf("error flattening ECS fieldsets: %v", err)
+		}
+
+		return &sourceData{
+			definitions: data,
+			fieldsets:   fieldsets,
+			positions:   nestedPositions(source.String(), contents),
+		}, nil
+	default:
+		data, err := readFlat(contents)
+		if err != nil {
+			return nil, err
+		}
+
+		return &sourceData{
+			definitions: data,
+			positions:   flatPositions(source.String(), contents),
+		}, nil
+	}
+}
+
+// readSourceFile reads the contents of a single file source from its file system.
+func readSourceFile(source config.Source) ([]byte, error) {
+	contents, err := fs.ReadFile(source.FileSystem(), source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ECS YAML: %v", err)
+	}
+
+	return contents, nil
+}
+
 // Root is used to get the loader's root definition tree.
 func (l *Loader) Root() *ecsgen.Root {
 	return l.root
@@ -0,0 +1,145 @@
+package loader
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gen0cide/ecsgen"
+)
+
+// ConflictError is returned when a schema source redefines a field that a previous
+// source already defined, and overrides have not been allowed.
+type ConflictError struct {
+	// FlatName is the ECS key of the conflicting field.
+	FlatName string
+
+	// Source is the file that attempted to redefine the field.
+	Source string
+
+	// Previous is the file that originally defined the field.
+	Previous string
+}
+
+// Error implements the error interface.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("field %s in %s conflicts with its definition in %s", e.FlatName, e.Source, e.Previous)
+}
+
+// sourceData holds everything that was read from a single source.
+type sourceData struct {
+	definitions map[string]*ecsgen.Definition
+	fieldsets   map[string]*ecsgen.Fieldset
+	positions   positions
+}
+
+// schema holds the merged result of every source the loader has read.
+type schema struct {
+	definitions map[string]*ecsgen.Definition
+	fieldsets   map[string]*ecsgen.Fieldset
+
+	// origins tracks which source file each definition came from
+	origins map[string]string
+
+	// positions tracks where in its source each definition was declared
+	positions positions
+}
+
+func newSchema() *schema {
+	return &schema{
+		definitions: map[string]*ecsgen.Definition{},
+		fieldsets:   map[string]*ecsgen.Fieldset{},
+		origins:     map[string]string{},
+		positions:   positions{},
+	}
+}
+
+// position returns where the definition of id was declared. If the source did not
+// provide line information, only the source is returned.
+func (s *schema) position(id string) Position {
+	if pos, found := s.positions[id]; found {
+		return pos
+	}
+
+	return Position{File: s.origins[id]}
+}
+
+// merge adds the definitions and fieldsets read from source into the schema. The rules are:
+//
+//   - A field that has not been seen before is added.
+//   - A field that is identical to its previous definition is ignored.
+//   - A field that differs from its previous definition is a *ConflictError, unless overrides
+//     are allowed. In that case, every non-zero value of the new definition replaces the value
+//     of the previous definition, so custom sources only need to specify what they change.
+func (s *schema) merge(source string, data *sourceData, allowOverrides bool) error {
+	for id, def := range data.definitions {
+		existing, found := s.definitions[id]
+		if !found {
+			s.definitions[id] = def
+			s.track(source, id, data)
+			continue
+		}
+
+		if reflect.DeepEqual(existing, def) {
+			continue
+		}
+
+		if !allowOverrides {
+			return &ConflictError{
+				FlatName: id,
+				Source:   source,
+				Previous: s.origins[id],
+			}
+		}
+
+		overlay(existing, def)
+		s.track(source, id, data)
+	}
+
+	for name, fieldset := range data.fieldsets {
+		existing, found := s.fieldsets[name]
+		if !found {
+			s.fieldsets[name] = fieldset
+			continue
+		}
+
+		// the fieldset metadata of the first source wins, but the fields
+		// of later sources are added so the fieldset stays complete
+		if existing.Fields == nil {
+			existing.Fields = map[string]*ecsgen.Definition{}
+		}
+
+		for key, def := range fieldset.Fields {
+			if _, found := existing.Fields[key]; !found {
+				existing.Fields[key] = def
+			}
+		}
+	}
+
+	return nil
+}
+
+// track records the source and position of the definition of id.
+func (s *schema) track(source string, id string, data *sourceData) {
+	s.origins[id] = source
+
+	if pos, found := data.positions[id]; found {
+		s.positions[id] = pos
+		return
+	}
+
+	delete(s.positions, id)
+}
+
+// overlay copies every non-zero field value of src onto dst.
+func overlay(dst, src *ecsgen.Definition) {
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.ValueOf(src).Elem()
+
+	for i := 0; i < sv.NumField(); i++ {
+		if sv.Field(i).IsZero() {
+			continue
+		}
+
+		dv.Field(i).Set(sv.Field(i))
+	}
+}
@@ -0,0 +1,141 @@
+package loader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gen0cide/ecsgen"
+)
+
+func TestSchemaMerge(t *testing.T) {
+	base := func() *sourceData {
+		return &sourceData{
+			definitions: map[string]*ecsgen.Definition{
+				"event.duration": {FlatName: "event.duration", Type: "long", Level: ecsgen.LevelCore, Description: "Duration of the event."},
+			},
+			positions: positions{
+				"event.duration": {File: "ecs_flat.yml", Line: 3},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		custom         *ecsgen.Definition
+		allowOverrides bool
+		wantConflict   bool
+		want           ecsgen.Definition
+		wantOrigin     string
+	}{
+		{
+			name:       "identical definition is ignored",
+			custom:     &ecsgen.Definition{FlatName: "event.duration", Type: "long", Level: ecsgen.LevelCore, Description: "Duration of the event."},
+			want:       ecsgen.Definition{FlatName: "event.duration", Type: "long", Level: ecsgen.LevelCore, Description: "Duration of the event."},
+			wantOrigin: "ecs_flat.yml",
+		},
+		{
+			name:         "different definition conflicts",
+			custom:       &ecsgen.Definition{FlatName: "event.duration", Type: "keyword"},
+			wantConflict: true,
+		},
+		{
+			name:           "allowed override replaces the values it sets",
+			custom:         &ecsgen.Definition{FlatName: "event.duration", Type: "keyword"},
+			allowOverrides: true,
+			want:           ecsgen.Definition{FlatName: "event.duration", Type: "keyword", Level: ecsgen.LevelCore, Description: "Duration of the event."},
+			wantOrigin:     "custom.yml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSchema()
+
+			err := s.merge("ecs_flat.yml", base(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.merge("custom.yml", &sourceData{
+				definitions: map[string]*ecsgen.Definition{
+					"event.duration": tt.custom,
+					"event.custom":   {FlatName: "event.custom", Type: "keyword"},
+				},
+			}, tt.allowOverrides)
+
+			if tt.wantConflict {
+				var conflict *ConflictError
+				if !errors.As(err, &conflict) {
+					t.Fatalf("merge() error = %v, want a *ConflictError", err)
+				}
+
+				if conflict.FlatName != "event.duration" || conflict.Source != "custom.yml" || conflict.Previous != "ecs_flat.yml" {
+					t.Errorf("merge() conflict = %+v", conflict)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("merge() error = %v", err)
+			}
+
+			if got := *s.definitions["event.duration"]; got.Type != tt.want.Type || got.Level != tt.want.Level || got.Description != tt.want.Description {
+				t.Errorf("merged definition = %+v, want %+v", got, tt.want)
+			}
+
+			if got := s.origins["event.duration"]; got != tt.wantOrigin {
+				t.Errorf("origin = %s, want %s", got, tt.wantOrigin)
+			}
+
+			// the custom source has no line information, so an override loses the position
+			wantPosition := Position{File: tt.wantOrigin}
+			if tt.wantOrigin == "ecs_flat.yml" {
+				wantPosition.Line = 3
+			}
+
+			if got := s.position("event.duration"); got != wantPosition {
+				t.Errorf("position = %v, want %v", got, wantPosition)
+			}
+
+			if _, found := s.definitions["event.custom"]; !found {
+				t.Errorf("new field from the custom source was not added")
+			}
+		})
+	}
+}
+
+func TestSchemaMergeFieldsets(t *testing.T) {
+	s := newSchema()
+
+	err := s.merge("ecs_nested.yml", &sourceData{
+		fieldsets: map[string]*ecsgen.Fieldset{
+			"event": {Name: "event", Title: "Event", Fields: map[string]*ecsgen.Definition{"kind": {Name: "kind"}}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.merge("custom.yml", &sourceData{
+		fieldsets: map[string]*ecsgen.Fieldset{
+			"event": {Name: "event", Title: "Custom", Fields: map[string]*ecsgen.Definition{"kind": {Name: "changed"}, "custom": {Name: "custom"}}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := s.fieldsets["event"]
+	if event.Title != "Event" {
+		t.Errorf("fieldset title = %s, want the title of the first source", event.Title)
+	}
+
+	if event.Fields["kind"].Name != "kind" {
+		t.Errorf("existing fieldset field was replaced by %s", event.Fields["kind"].Name)
+	}
+
+	if _, found := event.Fields["custom"]; !found {
+		t.Errorf("new fieldset field was not added")
+	}
+}
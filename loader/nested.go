@@ -0,0 +1,182 @@
+package loader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-ucfg/yaml"
+	"github.com/gen0cide/ecsgen"
+)
+
+// readNested parses the contents of an ECS generated "ecs_nested.yml" file into a map of fieldsets keyed by fieldset name.
+func readNested(contents []byte) (map[string]*ecsgen.Fieldset, error) {
+	var data map[string]*ecsgen.Fieldset
+
+	config, err := yaml.NewConfig(contents)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ECS YAML: %v", err)
+	}
+
+	err = config.Unpack(&data)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling YAML into ecsgen fieldsets: %v", err)
+	}
+
+	for name, fieldset := range data {
+		if fieldset.Name == "" {
+			fieldset.Name = name
+		}
+	}
+
+	return data, nil
+}
+
+// flattenFieldsets collects the field definitions of every fieldset into a single map keyed
+// by ECS key, the same shape that readFlat returns.
+func flattenFieldsets(fieldsets map[string]*ecsgen.Fieldset) (map[string]*ecsgen.Definition, error) {
+	data := map[string]*ecsgen.Definition{}
+
+	for name, fieldset := range fieldsets {
+		// reusable fieldsets that are not expected at the top level only
+		// exist at the locations they're nested in, which are listed by
+		// the fieldsets they are nested within.
+		if !fieldset.IsTopLevel() {
+			continue
+		}
+
+		for key, def := range fieldset.Fields {
+			// fall back to the fieldset prefix if the flat name was omitted
+			if def.FlatName == "" {
+				def.FlatName = fieldset.Prefix + key
+			}
+
+			if _, found := data[def.FlatName]; found {
+				return nil, fmt.Errorf("field %s is defined more than once (fieldset %s)", def.FlatName, name)
+			}
+
+			data[def.FlatName] = def
+		}
+	}
+
+	return data, nil
+}
+
+// linkFieldsets attaches the fieldset definitions to the object Nodes they describe. Nodes
+// are only linked if they survived filtering - no new Nodes are created.
+func linkFieldsets(root *ecsgen.Root, fieldsets map[string]*ecsgen.Fieldset) {
+	for name, fieldset := range fieldsets {
+		root.Fieldsets[name] = fieldset
+	}
+
+	for p, fieldset := range reusePaths(fieldsets) {
+		if node, found := root.Index[p]; found && node.IsObject() {
+			node.Fieldset = fieldset
+		}
+	}
+}
+
+// reusePaths returns the path of every object a fieldset is placed at, mapped to the fieldset.
+// Fieldsets are placed at the top level if they allow it, and nested at each of their expected
+// locations within every placement of the fieldset they are expected in, following the same
+// rules as the schemas source format. For example, when user is expected in client, and geo in
+// user as home, geo is placed at "client.user.home" as well as "user.home".
+func reusePaths(fieldsets map[string]*ecsgen.Fieldset) map[string]*ecsgen.Fieldset {
+	p := &placer{
+		fieldsets: fieldsets,
+		reuses:    map[string][]reuse{},
+		paths:     map[string]*ecsgen.Fieldset{},
+	}
+
+	names := []string{}
+	for name := range fieldsets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	// index every reusable fieldset by the fieldset it is expected in
+	for _, name := range names {
+		fieldset := fieldsets[name]
+		if !fieldset.IsReusable() {
+			continue
+		}
+
+		for _, location := range fieldset.Reusable.Expected {
+			target, sub := splitLocation(location.At)
+
+			as := location.As
+			if as == "" {
+				as = name
+			}
+
+			p.reuses[target] = append(p.reuses[target], reuse{
+				fieldset: name,
+				prefix:   sub,
+				as:       as,
+			})
+		}
+	}
+
+	for _, name := range names {
+		fieldset := fieldsets[name]
+
+		switch {
+		case fieldset.Root:
+			// root fieldsets (i.e. "base") place their fields directly in
+			// the top level namespace, so there is no object to link.
+			p.place(name, "", map[string]bool{name: true}, true)
+		case fieldset.IsTopLevel():
+			p.paths[name] = fieldset
+			p.place(name, name, map[string]bool{name: true}, true)
+		}
+	}
+
+	return p.paths
+}
+
+// placer collects the paths that fieldsets are placed at.
+type placer struct {
+	fieldsets map[string]*ecsgen.Fieldset
+	reuses    map[string][]reuse
+	paths     map[string]*ecsgen.Fieldset
+}
+
+// place nests the fieldsets reused within name under its placement at base. A fieldset nested
+// within itself is only copied at its own placements (i.e. "user.target", but not
+// "client.user.target"), and fieldsets that are already being placed are skipped, so cycles end.
+func (p *placer) place(name string, base string, visiting map[string]bool, self bool) {
+	for _, r := range p.reuses[name] {
+		fieldset, found := p.fieldsets[r.fieldset]
+		if !found {
+			continue
+		}
+
+		isSelf := r.fieldset == name
+		if (isSelf && !self) || (!isSelf && visiting[r.fieldset]) {
+			continue
+		}
+
+		nested := joinPath(base, r.prefix, r.as)
+		p.paths[nested] = fieldset
+
+		visiting[r.fieldset] = true
+		p.place(r.fieldset, nested, visiting, false)
+
+		if !isSelf {
+			delete(visiting, r.fieldset)
+		}
+	}
+}
+
+// joinPath joins the non-empty elements of a path with dots.
+func joinPath(elems ...string) string {
+	parts := []string{}
+	for _, x := range elems {
+		if x != "" {
+			parts = append(parts, x)
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
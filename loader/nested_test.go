@@ -0,0 +1,154 @@
+package loader
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// nestedSchema has a root fieldset, a fieldset that is only reused, and a fieldset that is
+// reused at the top level, within another fieldset and within itself.
+const nestedSchema = `
+base:
+  name: base
+  root: true
+  fields:
+    '@timestamp': {flat_name: '@timestamp', name: '@timestamp', type: date, level: core}
+client:
+  name: client
+  prefix: client.
+  fields:
+    address: {flat_name: client.address, name: address, type: keyword, level: extended}
+    user.name: {flat_name: client.user.name, name: name, type: keyword, level: core}
+    user.home.city_name: {flat_name: client.user.home.city_name, name: city_name, type: keyword, level: core}
+geo:
+  name: geo
+  prefix: geo.
+  reusable:
+    top_level: false
+    expected: [{at: user, as: home}]
+  fields:
+    city_name: {flat_name: geo.city_name, name: city_name, type: keyword, level: core}
+user:
+  name: user
+  prefix: user.
+  reusable:
+    top_level: true
+    expected: [client, {at: user, as: target}]
+  fields:
+    name: {name: name, type: keyword, level: core}
+    home.city_name: {flat_name: user.home.city_name, name: city_name, type: keyword, level: core}
+    target.name: {flat_name: user.target.name, name: name, type: keyword, level: core}
+    target.home.city_name: {flat_name: user.target.home.city_name, name: city_name, type: keyword, level: core}
+`
+
+func TestLoadNested(t *testing.T) {
+	l, err := loadTestSource(t, config.Source{
+		Path:   "ecs_nested.yml",
+		Format: config.SourceFormatNested,
+		FS:     fstest.MapFS{"ecs_nested.yml": {Data: []byte(nestedSchema)}},
+	}, config.Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// only the fields of top level fieldsets are loaded, and missing flat names use the prefix
+	want := []string{
+		"@timestamp",
+		"client.address",
+		"client.user.home.city_name",
+		"client.user.name",
+		"user.home.city_name",
+		"user.name",
+		"user.target.home.city_name",
+		"user.target.name",
+	}
+
+	if got := fields(l); !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() fields = %v, want %v", got, want)
+	}
+
+	root := l.Root()
+
+	names := []string{}
+	for name := range root.Fieldsets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if want := []string{"base", "client", "geo", "user"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Root().Fieldsets = %v, want %v", names, want)
+	}
+
+	tests := []struct {
+		path         string
+		wantFieldset string
+	}{
+		{path: "client", wantFieldset: "client"},
+		{path: "user", wantFieldset: "user"},
+		{path: "user.home", wantFieldset: "geo"},
+		{path: "user.target", wantFieldset: "user"},
+		{path: "user.target.home", wantFieldset: "geo"},
+		{path: "client.user", wantFieldset: "user"},
+		{path: "client.user.home", wantFieldset: "geo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node, found := root.Lookup(tt.path)
+			if !found {
+				t.Fatalf("%s is not in the tree", tt.path)
+			}
+
+			got := ""
+			if node.Fieldset != nil {
+				got = node.Fieldset.Name
+			}
+
+			if got != tt.wantFieldset {
+				t.Errorf("%s fieldset = %q, want %q", tt.path, got, tt.wantFieldset)
+			}
+		})
+	}
+}
+
+func TestReusePaths(t *testing.T) {
+	fieldsets, err := readNested([]byte(nestedSchema + `
+process:
+  name: process
+  prefix: process.
+  reusable:
+    top_level: true
+    expected: [{at: process, as: parent}, {at: process.parent, as: group_leader}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for p, fieldset := range reusePaths(fieldsets) {
+		got[p] = fieldset.Name
+	}
+
+	// self reuses are only copied at the fieldset's own placements
+	want := map[string]string{
+		"client":                      "client",
+		"client.user":                 "user",
+		"client.user.home":            "geo",
+		"process":                     "process",
+		"process.parent":              "process",
+		"process.parent.group_leader": "process",
+		"user":                        "user",
+		"user.home":                   "geo",
+		"user.target":                 "user",
+		"user.target.home":            "geo",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reusePaths() = %v, want %v", got, want)
+	}
+}
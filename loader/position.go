@@ -0,0 +1,188 @@
+package loader
+
+import (
+	"fmt"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Position identifies where a definition was declared within a source.
+type Position struct {
+	File string
+	Line int
+}
+
+// String implements the fmt.Stringer interface.
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+// positions maps ECS keys to the Position of their definition.
+type positions map[string]Position
+
+// parseNode parses YAML contents into a document node, returning nil if it cannot be parsed.
+// Positions are best effort - the sources are decoded separately, and that is where syntax
+// errors are reported.
+func parseNode(contents []byte) *yamlv3.Node {
+	doc := &yamlv3.Node{}
+
+	err := yamlv3.Unmarshal(contents, doc)
+	if err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+
+	return doc.Content[0]
+}
+
+// mappingValue returns the value of key within a mapping node, or nil if it is not present.
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// flatPositions indexes the definitions of an "ecs_flat.yml" file, which are keyed by ECS key.
+func flatPositions(file string, contents []byte) positions {
+	ret := positions{}
+
+	node := parseNode(contents)
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return ret
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		ret[key.Value] = Position{File: file, Line: key.Line}
+	}
+
+	return ret
+}
+
+// nestedPositions indexes the definitions of an "ecs_nested.yml" file, which are grouped by fieldset.
+func nestedPositions(file string, contents []byte) positions {
+	ret := positions{}
+
+	node := parseNode(contents)
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return ret
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fieldset := node.Content[i+1]
+
+		prefix := ""
+		if p := mappingValue(fieldset, "prefix"); p != nil {
+			prefix = p.Value
+		}
+
+		fields := mappingValue(fieldset, "fields")
+		if fields == nil || fields.Kind != yamlv3.MappingNode {
+			continue
+		}
+
+		for j := 0; j+1 < len(fields.Content); j += 2 {
+			key := fields.Content[j]
+
+			flatName := prefix + key.Value
+			if fn := mappingValue(fields.Content[j+1], "flat_name"); fn != nil {
+				flatName = fn.Value
+			}
+
+			ret[flatName] = Position{File: file, Line: key.Line}
+		}
+	}
+
+	return ret
+}
+
+// schemaPositions indexes the fields of an upstream ECS schema file. As the fields of reusable
+// fieldsets are copied to many locations, they are keyed by the fieldset name and the field
+// name (i.e. "geo.city_name"), or just the field name for root fieldsets.
+func schemaPositions(file string, contents []byte) positions {
+	ret := positions{}
+
+	node := parseNode(contents)
+	if node == nil || node.Kind != yamlv3.SequenceNode {
+		return ret
+	}
+
+	for _, fieldset := range node.Content {
+		name := mappingValue(fieldset, "name")
+		if name == nil {
+			continue
+		}
+
+		prefix := name.Value + "."
+		if root := mappingValue(fieldset, "root"); root != nil && root.Value == "true" {
+			prefix = ""
+		}
+
+		fields := mappingValue(fieldset, "fields")
+		if fields == nil || fields.Kind != yamlv3.SequenceNode {
+			continue
+		}
+
+		for _, field := range fields.Content {
+			if fieldName := mappingValue(field, "name"); fieldName != nil {
+				ret[prefix+fieldName.Value] = Position{File: file, Line: field.Line}
+			}
+		}
+	}
+
+	return ret
+}
+
+// beatsPositions indexes the fields of a Beats "fields.yml" file, following the same
+// grouping rules as readBeatsFields.
+func beatsPositions(file string, contents []byte) positions {
+	ret := positions{}
+
+	node := parseNode(contents)
+	if node == nil || node.Kind != yamlv3.SequenceNode {
+		return ret
+	}
+
+	var walk func(prefix string, fields *yamlv3.Node)
+	walk = func(prefix string, fields *yamlv3.Node) {
+		if fields == nil || fields.Kind != yamlv3.SequenceNode {
+			return
+		}
+
+		for _, field := range fields.Content {
+			name := mappingValue(field, "name")
+			if name == nil {
+				continue
+			}
+
+			if t := mappingValue(field, "type"); t != nil && t.Value == "group" {
+				walk(prefix+name.Value+".", mappingValue(field, "fields"))
+				continue
+			}
+
+			ret[prefix+name.Value] = Position{File: file, Line: field.Line}
+		}
+	}
+
+	for _, entry := range node.Content {
+		if mappingValue(entry, "key") != nil {
+			walk("", mappingValue(entry, "fields"))
+			continue
+		}
+
+		walk("", &yamlv3.Node{Kind: yamlv3.SequenceNode, Content: []*yamlv3.Node{entry}})
+	}
+
+	return ret
+}
@@ -0,0 +1,356 @@
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-ucfg/yaml"
+	"github.com/gen0cide/ecsgen"
+)
+
+// defaultIgnoreAbove is the ignore_above value the ECS tooling assigns keyword fields that do not set one.
+const defaultIgnoreAbove = 1024
+
+// rawFieldset represents a single fieldset entry within the upstream ECS "schemas/*.yml" files.
+// It differs from ecsgen.Fieldset in that the fields are a list of definitions relative
+// to the fieldset, rather than a map keyed by flat name.
+// nolint:maligned
+type rawFieldset struct {
+	Name        string               `config:"name"`
+	Title       string               `config:"title"`
+	Group       int                  `config:"group"`
+	Short       string               `config:"short"`
+	Description string               `config:"description"`
+	Footnote    string               `config:"footnote"`
+	Type        string               `config:"type"`
+	Root        bool                 `config:"root"`
+	Reusable    *ecsgen.Reusable     `config:"reusable"`
+	Fields      []*ecsgen.Definition `config:"fields"`
+}
+
+// relativeField is a field definition along with its name relative to the fieldset it will be placed in.
+type relativeField struct {
+	name     string
+	fieldset string
+	def      *ecsgen.Definition
+}
+
+// readSchemas parses every fieldset file within an upstream ECS "schemas" directory and expands
+// them into the same shape that readFlat returns. Reusable fieldsets are nested at each of their
+// expected locations, and are only placed at the top level if the fieldset allows it.
+func readSchemas(fsys fs.FS, dirname string) (*sourceData, error) {
+	files, err := fs.ReadDir(fsys, dirname)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ECS schema directory: %v", err)
+	}
+
+	raw := map[string]*rawFieldset{}
+	rawPositions := positions{}
+
+	// files are returned sorted by name, so the order fieldsets are read is deterministic
+	for _, file := range files {
+		ext := path.Ext(file.Name())
+		if file.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		var entries []*rawFieldset
+
+		filename := path.Join(dirname, file.Name())
+
+		contents, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ECS YAML %s: %v", file.Name(), err)
+		}
+
+		config, err := yaml.NewConfig(contents)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ECS YAML %s: %v", file.Name(), err)
+		}
+
+		err = config.Unpack(&entries)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling YAML %s into ecsgen fieldsets: %v", file.Name(), err)
+		}
+
+		for key, pos := range schemaPositions(filename, contents) {
+			rawPositions[key] = pos
+		}
+
+		for _, entry := range entries {
+			if entry.Name == "" {
+				return nil, fmt.Errorf("fieldset without a name in %s", file.Name())
+			}
+
+			if _, found := raw[entry.Name]; found {
+				return nil, fmt.Errorf("fieldset %s is defined more than once (%s)", entry.Name, file.Name())
+			}
+
+			raw[entry.Name] = entry
+		}
+	}
+
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no fieldsets found in %s", dirname)
+	}
+
+	e := &expander{
+		raw:    raw,
+		reuses: map[string][]reuse{},
+	}
+
+	// index every reusable fieldset by the fieldset it is expected in
+	for _, name := range e.names() {
+		fieldset := raw[name]
+		if fieldset.Reusable == nil {
+			continue
+		}
+
+		for _, location := range fieldset.Reusable.Expected {
+			target, sub := splitLocation(location.At)
+			if _, found := raw[target]; !found {
+				return nil, fmt.Errorf("fieldset %s is expected in unknown fieldset %s", name, target)
+			}
+
+			// fieldsets are nested under their own name unless the location renames them
+			as := location.As
+			if as == "" {
+				as = name
+			}
+
+			e.reuses[target] = append(e.reuses[target], reuse{
+				fieldset: name,
+				prefix:   sub,
+				as:       as,
+			})
+		}
+	}
+
+	ret := &sourceData{
+		definitions: map[string]*ecsgen.Definition{},
+		fieldsets:   map[string]*ecsgen.Fieldset{},
+		positions:   positions{},
+	}
+
+	for _, name := range e.names() {
+		fieldset := e.fieldset(name)
+
+		fields, err := e.fields(name, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range fields {
+			def := expandDefinition(field, fieldset.Prefix+field.name, name)
+			fieldset.Fields[field.name] = def
+
+			// reusable fieldsets that are not expected at the top level
+			// only exist at the locations they're nested in
+			if !fieldset.IsTopLevel() {
+				continue
+			}
+
+			if _, found := ret.definitions[def.FlatName]; found {
+				return nil, fmt.Errorf("field %s is defined more than once (fieldset %s)", def.FlatName, name)
+			}
+
+			ret.definitions[def.FlatName] = def
+
+			// reused fields point back to where the original field was declared
+			if pos, found := rawPositions[e.prefix(field.fieldset)+def.Name]; found {
+				ret.positions[def.FlatName] = pos
+			}
+		}
+
+		ret.fieldsets[name] = fieldset
+	}
+
+	return ret, nil
+}
+
+// reuse describes a reusable fieldset that is nested within another fieldset. The fields
+// are placed at "<prefix>.<as>" within the fieldset, or "<as>" if the prefix is empty.
+type reuse struct {
+	fieldset string
+	prefix   string
+	as       string
+}
+
+// expander resolves the complete list of fields for each fieldset, including reused fieldsets.
+type expander struct {
+	raw    map[string]*rawFieldset
+	reuses map[string][]reuse
+}
+
+// names returns the fieldset names in sorted order.
+func (e *expander) names() []string {
+	names := []string{}
+	for name := range e.raw {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// fieldset converts a raw fieldset into an ecsgen.Fieldset without any fields.
+func (e *expander) fieldset(name string) *ecsgen.Fieldset {
+	raw := e.raw[name]
+
+	return &ecsgen.Fieldset{
+		Name:        raw.Name,
+		Title:       raw.Title,
+		Group:       raw.Group,
+		Short:       raw.Short,
+		Description: raw.Description,
+		Footnote:    raw.Footnote,
+		Type:        raw.Type,
+		Root:        raw.Root,
+		Reusable:    raw.Reusable,
+		Prefix:      e.prefix(name),
+		Fields:      map[string]*ecsgen.Definition{},
+	}
+}
+
+// prefix returns the prefix of the fields of a fieldset. Root fieldsets have no prefix.
+func (e *expander) prefix(name string) string {
+	if e.raw[name].Root {
+		return ""
+	}
+
+	return name + "."
+}
+
+// fields returns the fields of a fieldset relative to it, followed by the fields of every
+// fieldset reused within it. For example, fields("client") includes "geo.city_name", and
+// fields("process") includes "parent.pid" when process is reused within itself as parent.
+func (e *expander) fields(name string, visiting map[string]bool) ([]relativeField, error) {
+	ret, err := e.foreignFields(name, visiting)
+	if err != nil {
+		return nil, err
+	}
+
+	// a fieldset nested within itself gets a copy of its fields, without the other
+	// copies of itself (i.e. "process.parent", but not "process.parent.parent")
+	for _, r := range e.reuses[name] {
+		if r.fieldset != name {
+			continue
+		}
+
+		nested, err := e.foreignFields(name, visiting)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, r.nest(nested)...)
+	}
+
+	return ret, nil
+}
+
+// foreignFields returns the fields of a fieldset relative to it, followed by the fields of
+// every other fieldset reused within it. Fieldsets reused within themselves are skipped, so
+// "client.user" does not get the "user.target" copy of user.
+func (e *expander) foreignFields(name string, visiting map[string]bool) ([]relativeField, error) {
+	if visiting[name] {
+		return nil, fmt.Errorf("fieldset %s is reused within itself", name)
+	}
+
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	ret := []relativeField{}
+
+	for _, def := range e.raw[name].Fields {
+		if def.Name == "" {
+			return nil, fmt.Errorf("field without a name in fieldset %s", name)
+		}
+
+		ret = append(ret, relativeField{
+			name:     def.Name,
+			fieldset: name,
+			def:      def,
+		})
+	}
+
+	for _, r := range e.reuses[name] {
+		if r.fieldset == name {
+			continue
+		}
+
+		nested, err := e.foreignFields(r.fieldset, visiting)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, r.nest(nested)...)
+	}
+
+	return ret, nil
+}
+
+// nest moves the fields of a reused fieldset to the location they are reused at.
+func (r reuse) nest(fields []relativeField) []relativeField {
+	prefix := r.as + "."
+	if r.prefix != "" {
+		prefix = r.prefix + "." + prefix
+	}
+
+	ret := make([]relativeField, len(fields))
+	for idx, field := range fields {
+		field.name = prefix + field.name
+		ret[idx] = field
+	}
+
+	return ret
+}
+
+// expandDefinition copies the definition of a field and fills in the values that the ECS tooling
+// derives when generating "ecs_flat.yml".
+func expandDefinition(field relativeField, flatName string, fieldset string) *ecsgen.Definition {
+	def := *field.def
+
+	def.FlatName = flatName
+	def.DashedName = dashedName(flatName)
+
+	if field.fieldset != fieldset {
+		def.OriginalFieldset = field.fieldset
+	}
+
+	if def.Type == "keyword" && def.IgnoreAbove == 0 {
+		def.IgnoreAbove = defaultIgnoreAbove
+	}
+
+	// multi fields need their own copies, as the flat name differs per location
+	if len(def.MultiFields) > 0 {
+		def.MultiFields = make([]*ecsgen.MultiField, len(field.def.MultiFields))
+		for idx, mf := range field.def.MultiFields {
+			copied := *mf
+			copied.FlatName = flatName + "." + mf.Name
+			def.MultiFields[idx] = &copied
+		}
+	}
+
+	return &def
+}
+
+// splitLocation splits an expected location into the fieldset it belongs to and the path within
+// that fieldset. For example, "process.parent" => ("process", "parent").
+func splitLocation(location string) (string, string) {
+	parts := strings.SplitN(location, ".", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// dashedName derives the dashed_name ECS assigns a field from its flat name.
+// For example, "client.geo.city_name" => "client-geo-city-name".
+func dashedName(flatName string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(flatName)
+}
@@ -0,0 +1,153 @@
+package loader
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// schemaFiles is an upstream style "schemas" directory with a root fieldset, a fieldset that is
+// only reused, fieldsets reused under another name, and fieldsets reused within themselves.
+var schemaFiles = fstest.MapFS{
+	"schemas/base.yml": {Data: []byte(`
+- name: base
+  root: true
+  fields:
+    - {name: "@timestamp", type: date, level: core}
+`)},
+	"schemas/client.yml": {Data: []byte(`
+- name: client
+  fields:
+    - {name: address, type: keyword, level: extended}
+`)},
+	"schemas/geo.yml": {Data: []byte(`
+- name: geo
+  reusable:
+    top_level: false
+    expected: [client, {at: user, as: home}]
+  fields:
+    - name: city_name
+      type: keyword
+      level: core
+      multi_fields:
+        - {name: text, type: text}
+`)},
+	"schemas/process.yml": {Data: []byte(`
+- name: process
+  reusable:
+    top_level: true
+    expected: [{at: process, as: parent}]
+  fields:
+    - {name: pid, type: long, level: core}
+`)},
+	"schemas/user.yml": {Data: []byte(`
+- name: user
+  reusable:
+    top_level: true
+    expected: [client, {at: user, as: target}]
+  fields:
+    - {name: name, type: keyword, level: core}
+`)},
+	"schemas/README.md": {Data: []byte("not a fieldset")},
+}
+
+func TestLoadSchemas(t *testing.T) {
+	l, err := loadTestSource(t, config.Source{
+		Path:   "schemas",
+		Format: config.SourceFormatSchemas,
+		FS:     schemaFiles,
+	}, config.Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// geo is only placed where it is reused, and a self reuse is not repeated within reuses of
+	// the fieldset (i.e. there is no "client.user.target")
+	want := []string{
+		"@timestamp",
+		"client.address",
+		"client.geo.city_name",
+		"client.user.home.city_name",
+		"client.user.name",
+		"process.parent.pid",
+		"process.pid",
+		"user.home.city_name",
+		"user.name",
+		"user.target.home.city_name",
+		"user.target.name",
+	}
+
+	if got := fields(l); !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() fields = %v, want %v", got, want)
+	}
+
+	root := l.Root()
+
+	tests := []struct {
+		path         string
+		wantFieldset string
+	}{
+		{path: "client", wantFieldset: "client"},
+		{path: "client.geo", wantFieldset: "geo"},
+		{path: "client.user", wantFieldset: "user"},
+		{path: "client.user.home", wantFieldset: "geo"},
+		{path: "process", wantFieldset: "process"},
+		{path: "process.parent", wantFieldset: "process"},
+		{path: "user", wantFieldset: "user"},
+		{path: "user.home", wantFieldset: "geo"},
+		{path: "user.target", wantFieldset: "user"},
+		{path: "user.target.home", wantFieldset: "geo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node, found := root.Lookup(tt.path)
+			if !found {
+				t.Fatalf("%s is not in the tree", tt.path)
+			}
+
+			got := ""
+			if node.Fieldset != nil {
+				got = node.Fieldset.Name
+			}
+
+			if got != tt.wantFieldset {
+				t.Errorf("%s fieldset = %q, want %q", tt.path, got, tt.wantFieldset)
+			}
+		})
+	}
+}
+
+func TestLoadSchemasDefinitions(t *testing.T) {
+	l, err := loadTestSource(t, config.Source{
+		Path:   "schemas",
+		Format: config.SourceFormatSchemas,
+		FS:     schemaFiles,
+	}, config.Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	node, _ := l.Root().Lookup("client.user.home.city_name")
+	def := node.Definition
+
+	// reused fields are filled in the way the ECS tooling does, for the location they are at
+	if def.FlatName != "client.user.home.city_name" || def.DashedName != "client-user-home-city-name" {
+		t.Errorf("names = %s, %s", def.FlatName, def.DashedName)
+	}
+
+	if def.OriginalFieldset != "geo" || def.IgnoreAbove != defaultIgnoreAbove {
+		t.Errorf("original fieldset = %q, ignore_above = %d", def.OriginalFieldset, def.IgnoreAbove)
+	}
+
+	if len(def.MultiFields) != 1 || def.MultiFields[0].FlatName != "client.user.home.city_name.text" {
+		t.Errorf("multi_fields = %+v", def.MultiFields)
+	}
+
+	// reused fields point back to where the original field was declared
+	if pos := l.schema.position("client.user.home.city_name"); pos.File != "schemas/geo.yml" || pos.Line == 0 {
+		t.Errorf("position = %s, want a line in schemas/geo.yml", pos)
+	}
+}
@@ -0,0 +1,187 @@
+package loader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gen0cide/ecsgen"
+)
+
+const (
+	// SeverityError denotes a Diagnostic that makes the schema unusable.
+	SeverityError = "error"
+
+	// SeverityWarning denotes a Diagnostic that is suspicious, but does not prevent generation.
+	SeverityWarning = "warning"
+)
+
+// Diagnostic describes a single problem found while validating the loaded schema.
+type Diagnostic struct {
+	Severity string
+	Position Position
+	Field    string
+	Message  string
+}
+
+// String implements the fmt.Stringer interface.
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s: %s", d.Position, d.Severity, d.Field, d.Message)
+}
+
+// Diagnostics is a list of Diagnostic values. It implements the error interface so that
+// the result of validation can be returned as an error when it contains errors.
+type Diagnostics []*Diagnostic
+
+// Error implements the error interface.
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for idx, x := range d {
+		lines[idx] = x.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// HasErrors returns true if any Diagnostic has a severity of SeverityError.
+func (d Diagnostics) HasErrors() bool {
+	return d.Errors() > 0
+}
+
+// Errors returns the number of Diagnostics with a severity of SeverityError.
+func (d Diagnostics) Errors() int {
+	count := 0
+	for _, x := range d {
+		if x.Severity == SeverityError {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Validate checks the definitions that passed the filters, and returns a Diagnostic for each
+// problem found. Definitions that were filtered out are never generated, so they are not
+// checked. It should be called after Load.
+func (l *Loader) Validate() Diagnostics {
+	if l.schema == nil {
+		return Diagnostics{}
+	}
+
+	v := &validator{
+		schema: l.schema,
+		diags:  Diagnostics{},
+	}
+
+	for _, id := range l.selected {
+		v.validate(id, l.schema.definitions[id])
+	}
+
+	// fields moved by the collision strategy are worth a warning. any other collision
+	// already failed Load.
+	for _, c := range l.collisions {
+		if c.movedTo != "" {
+			v.collision(c)
+		}
+	}
+
+	return v.diags
+}
+
+// validator accumulates the diagnostics for a schema.
+type validator struct {
+	schema *schema
+	diags  Diagnostics
+}
+
+func (v *validator) report(severity string, id string, format string, args ...interface{}) {
+	v.diags = append(v.diags, &Diagnostic{
+		Severity: severity,
+		Position: v.schema.position(id),
+		Field:    id,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate(id string, def *ecsgen.Definition) {
+	// the flat name should always match the key it was defined with
+	switch def.FlatName {
+	case "":
+		v.report(SeverityWarning, id, "flat_name is not set")
+	case id:
+	default:
+		v.report(SeverityError, id, "flat_name %q does not match the field key", def.FlatName)
+	}
+
+	if !contains(ecsgen.FieldTypes, def.Type) {
+		v.report(SeverityError, id, "unknown type %q", def.Type)
+	}
+
+	// fields without a level are not part of ECS, so they are custom, as they are for the filters
+	if !contains(ecsgen.Levels, levelOf(def)) {
+		v.report(SeverityError, id, "level %q is not one of %s", def.Level, strings.Join(ecsgen.Levels, ", "))
+	}
+
+	for _, x := range def.Normalize {
+		if !contains(ecsgen.NormalizeValues, x) {
+			v.report(SeverityError, id, "unknown normalize value %q", x)
+		}
+	}
+
+	for idx, mf := range def.MultiFields {
+		switch {
+		case mf.Name == "":
+			v.report(SeverityError, id, "multi_fields[%d] has no name", idx)
+		case mf.FlatName != "" && mf.FlatName != id+"."+mf.Name:
+			v.report(SeverityError, id, "multi_fields[%d] flat_name %q should be %q", idx, mf.FlatName, id+"."+mf.Name)
+		}
+
+		if !contains(ecsgen.FieldTypes, mf.Type) {
+			v.report(SeverityError, id, "multi_fields[%d] has unknown type %q", idx, mf.Type)
+		}
+	}
+
+	if len(def.AllowedValues) > 0 && def.Type != "keyword" {
+		v.report(SeverityError, id, "allowed_values can only be used on keyword fields, not %s", def.Type)
+	}
+
+	for idx, av := range def.AllowedValues {
+		if av.Name == "" {
+			v.report(SeverityError, id, "allowed_values[%d] has no name", idx)
+		}
+	}
+}
+
+// collision reports a scalar field that is also used as the parent object of other fields.
+// It is only a warning if the collision strategy moved the field out of the way.
+func (v *validator) collision(c *collision) {
+	def := v.schema.definitions[c.field]
+
+	switch {
+	case c.movedTo != "":
+		v.report(SeverityWarning, c.field, "%s field is also used as the parent object of %s, and will be moved to %s", def.Type, c.child, c.movedTo)
+	case c.blocked:
+		v.report(SeverityError, c.field, "%s field is also used as the parent object of %s, and cannot be moved: a field with the suffixed name already exists", def.Type, c.child)
+	default:
+		v.report(SeverityError, c.field, "%s field is also used as the parent object of %s", def.Type, c.child)
+	}
+}
+
+// parentPath returns the path of the parent of an ECS key, or an empty string for top level keys.
+func parentPath(id string) string {
+	idx := strings.LastIndex(id, ".")
+	if idx == -1 {
+		return ""
+	}
+
+	return id[:idx]
+}
+
+func contains(list []string, val string) bool {
+	for _, x := range list {
+		if x == val {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package loader
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gen0cide/ecsgen/config"
+)
+
+// validateSchema has a valid field of every type added for newer ECS versions, a field without
+// a level, and an invalid field in its own fieldset.
+const validateSchema = `
+event.ingested: {flat_name: event.ingested, name: ingested, type: date_nanos, level: core}
+event.sequence: {flat_name: event.sequence, name: sequence, type: unsigned_long, level: extended}
+event.message: {flat_name: event.message, name: message, type: match_only_text, level: core}
+event.shape: {flat_name: event.shape, name: shape, type: geo_shape, level: custom}
+event.latency: {flat_name: event.latency, name: latency, type: histogram, level: custom}
+event.alias: {flat_name: event.alias, name: alias, type: alias, level: custom}
+event.custom: {flat_name: event.custom, name: custom, type: keyword}
+broken.kind: {flat_name: broken.other, name: kind, type: txt, level: core}
+broken.level: {flat_name: broken.level, name: level, type: keyword, level: basic}
+`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters config.Filters
+		want    []string
+	}{
+		{
+			name: "every selected definition is checked",
+			want: []string{
+				`error: broken.kind: flat_name "broken.other" does not match the field key`,
+				`error: broken.kind: unknown type "txt"`,
+				`error: broken.level: level "basic" is not one of core, extended, custom`,
+			},
+		},
+		{
+			name:    "filtered definitions are not checked",
+			filters: config.Filters{ExcludeFieldsets: []string{"broken"}},
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLoader(t, validateSchema, config.Options{Filters: tt.filters})
+
+			got := []string{}
+			for _, d := range l.Validate() {
+				got = append(got, d.Severity+": "+d.Field+": "+d.Message)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
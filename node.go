@@ -30,6 +30,8 @@ type Node struct {
 	// Children are a map of all child nodes that belong to this node. The key
 	// is the Name field of the child, and the value is a pointer to the Node itself.
 	// Example: Node("client.nat") has a child ["ip"] => Node("client.nat.ip").
+	// This should not be modified directly - use Child and Remove instead, which
+	// keep the Index and the sorted children consistent.
 	Children map[string]*Node
 
 	// Definition is used to link back to the source of truth YAML definition
@@ -37,6 +39,16 @@ type Node struct {
 	// that are of type "field", but is generally nil for objects, as ECS
 	// treats them mostly as implicit.
 	Definition *Definition
+
+	// Fieldset is used to link an object Node back to the fieldset definition it
+	// represents. This is only populated when the schema was loaded from a source
+	// that carries fieldset metadata, such as "ecs_nested.yml". Reusable fieldsets
+	// are linked to every location they are nested at (ex: "client.geo" and "geo").
+	Fieldset *Fieldset
+
+	// sorted holds the same Nodes as Children, sorted by Name. It is maintained
+	// by Child and Remove so walking the tree never needs to sort.
+	sorted []*Node
 }
 
 // IsTopLevel returns true if the Node has no Parent, therefor indicating it
@@ -49,17 +61,28 @@ func (n *Node) IsTopLevel() bool {
 // should be a relative "Name" and *not* an absolute path. For example, if you wanted
 // to retrieve the "client.nat" child from the "client" Node, you would pass "nat".
 // If the child does not exist, it is created. If it does exist, the existing child is returned.
-// If you pass it an empty string, the Node will simply return itself.
-func (n *Node) Child(name string) *Node {
+// If you pass it an empty string, the Node will simply return itself. A *SchemaError is
+// returned if the name contains a path separator, or if the child does not exist and the
+// tree is read-only.
+func (n *Node) Child(name string) (*Node, error) {
 	// short circuit check to see if we have this child already
 	if child, found := n.Children[name]; found {
-		return child
+		return child, nil
 	}
 
 	// if the caller passes us an empty child, that must mean they
 	// think we're to be resolved.
 	if name == "" {
-		return n
+		return n, nil
+	}
+
+	// names are a single element of a path
+	if strings.Contains(name, ".") {
+		return nil, &SchemaError{Path: n.Path, Field: name, Cause: ErrInvalidName}
+	}
+
+	if n.Root.readOnly {
+		return nil, &SchemaError{Path: n.Path, Field: name, Cause: ErrReadOnly}
 	}
 
 	// Create the new Node
@@ -76,27 +99,119 @@ func (n *Node) Child(name string) *Node {
 
 	// Add the new Node to the current Node's children
 	n.Children[name] = newChild
+	n.sorted = insertSorted(n.sorted, newChild)
+
+	return newChild, nil
+}
+
+// Lookup is used to resolve an existing descendant of the Node by a path relative to it. For
+// example, Node("client").Lookup("nat.ip") returns Node("client.nat.ip"). Unlike Child,
+// it never creates Nodes. If you pass it an empty string, the Node will simply return itself.
+func (n *Node) Lookup(relpath string) (*Node, bool) {
+	if relpath == "" {
+		return n, true
+	}
+
+	node := n
+	for _, name := range strings.Split(relpath, ".") {
+		child, found := node.Children[name]
+		if !found {
+			return nil, false
+		}
+
+		node = child
+	}
+
+	return node, true
+}
+
+// Remove removes the child with the given Name, along with all of its children, from the
+// Node. The root Index is updated to match. It returns false if the child does not exist,
+// or if the tree is read-only.
+func (n *Node) Remove(name string) bool {
+	child, found := n.Children[name]
+	if !found || n.Root.readOnly {
+		return false
+	}
 
-	return newChild
+	delete(n.Children, name)
+	n.sorted = removeSorted(n.sorted, child)
+	n.Root.unindex(child)
+
+	return true
+}
+
+// Prune removes every descendant object Node that has neither a Definition nor any children,
+// working from the leaves up. The Node itself is not removed. It returns the number of Nodes removed,
+// which is always 0 for a read-only tree.
+func (n *Node) Prune() int {
+	count := 0
+
+	if n.Root.readOnly {
+		return count
+	}
+
+	for name, child := range n.Children {
+		count += child.Prune()
+
+		if child.isPrunable() {
+			n.Remove(name)
+			count++
+		}
+	}
+
+	return count
+}
+
+// isPrunable returns true if the Node is an object without a Definition or any children left.
+func (n *Node) isPrunable() bool {
+	return n.Definition == nil && len(n.Children) == 0
 }
 
 // TypeIdent creates an Identifier based on the Node's type. This is almost never called
 // for fields, but is required for objects. *Node.GoType() uses this to create object types.
-// The returned Identifier is equal to NewIdentifier(n.Path).
+// The returned Identifier keeps the Initialisms of the Node's Root capitalized.
 func (n *Node) TypeIdent() Identifier {
-	return NewIdentifier(n.Path)
+	return n.identifier(n.Path)
 }
 
 // FieldIdent creates an Identifier that can be used as a field reference. This is used
-// to create Go struct fields for every Node. The returned Identifier is equal to NewIdentifier(n.Name).
+// to create Go struct fields for every Node. The returned Identifier keeps the Initialisms
+// of the Node's Root capitalized.
 func (n *Node) FieldIdent() Identifier {
-	return NewIdentifier(n.Name)
+	return n.identifier(n.Name)
+}
+
+// identifier creates an Identifier with the Initialisms of the Node's Root, if it has one.
+func (n *Node) identifier(s string) Identifier {
+	if n.Root == nil {
+		return NewIdentifier(s)
+	}
+
+	return n.Root.Identifier(s)
 }
 
 // IsImplied IsImplied is used to determine if a node is implied via the schema. This is
-// true for the majority of objects in the schema, while false for all fields.
+// true for the majority of objects in the schema, while false for all fields and for
+// objects that are backed by a Fieldset.
 func (n *Node) IsImplied() bool {
-	return n.Definition == nil
+	return n.Definition == nil && n.Fieldset == nil
+}
+
+// IsFieldset returns true if the Node represents an ECS fieldset, either at its
+// top level location or nested within another fieldset.
+func (n *Node) IsFieldset() bool {
+	return n.Fieldset != nil
+}
+
+// IsReused returns true if the Node is a reusable fieldset that has been nested
+// within another fieldset. For example, Node("client.geo") is a reuse of "geo".
+func (n *Node) IsReused() bool {
+	if n.Fieldset == nil || !n.Fieldset.IsReusable() {
+		return false
+	}
+
+	return n.Path != n.Fieldset.Name
 }
 
 // IsObject attempts to determine if the Node is an "object" or a "field" and returns
@@ -127,8 +242,8 @@ func (n *Node) IsObject() bool {
 // IsArray is used to determine if the given Node within the ECS schema is
 // actually an array of it's noted data type.
 func (n *Node) IsArray() bool {
-	// Not an array if it's implied
-	if n.IsImplied() {
+	// Not an array if it's an implied object or a fieldset
+	if n.Definition == nil {
 		return false
 	}
 
@@ -149,32 +264,62 @@ func (n *Node) IsArray() bool {
 	return false
 }
 
-// ListChildren implements the Walkable interface.
+// ChildNodes implements the Walkable interface. The returned slice is shared with the Node
+// and must not be modified. It is only valid until a child is added or removed, as that
+// updates the slice in place.
+func (n *Node) ChildNodes() []*Node {
+	return n.sorted
+}
+
+// ListChildren returns a channel that yields the children of the Node, sorted by Name.
+//
+// Deprecated: ListChildren allocates a channel on every call and is no longer part of the
+// Walkable interface. Use ChildNodes instead.
 func (n *Node) ListChildren() <-chan *Node {
-	ret := make(chan *Node, len(n.Children))
+	return listNodes(n.sorted)
+}
 
-	// short circuit if there's no children
-	if len(n.Children) == 0 {
-		close(ret)
-		return ret
-	}
+// listNodes returns a closed channel that yields nodes in order, for the deprecated ListChildren methods.
+func listNodes(nodes []*Node) <-chan *Node {
+	ret := make(chan *Node, len(nodes))
 
-	// get all the child keys
-	keys := []string{}
-	for k := range n.Children {
-		keys = append(keys, k)
+	for _, node := range nodes {
+		ret <- node
 	}
 
-	// sort them alphabetically
-	sort.Strings(keys)
+	close(ret)
+
+	return ret
+}
+
+// insertSorted inserts node into a slice of Nodes sorted by Name, keeping it sorted. The
+// slice is updated in place, so only growing it past its capacity allocates.
+func insertSorted(nodes []*Node, node *Node) []*Node {
+	idx := sort.Search(len(nodes), func(i int) bool {
+		return nodes[i].Name >= node.Name
+	})
 
-	// add the keys in order to the channel
-	for _, k := range keys {
-		ret <- n.Children[k]
+	nodes = append(nodes, nil)
+	copy(nodes[idx+1:], nodes[idx:])
+	nodes[idx] = node
+
+	return nodes
+}
+
+// removeSorted removes node from a slice of Nodes sorted by Name. The slice is updated in place.
+func removeSorted(nodes []*Node, node *Node) []*Node {
+	idx := sort.Search(len(nodes), func(i int) bool {
+		return nodes[i].Name >= node.Name
+	})
+
+	if idx == len(nodes) || nodes[idx] != node {
+		return nodes
 	}
 
-	close(ret)
+	copy(nodes[idx:], nodes[idx+1:])
 
-	// return the channel
-	return ret
+	// clear the last element, so the removed Node can be garbage collected
+	nodes[len(nodes)-1] = nil
+
+	return nodes[:len(nodes)-1]
 }
@@ -0,0 +1,218 @@
+package ecsgen
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// benchmarkPaths returns the paths of a tree about the size of the ECS schema, in a random
+// but repeatable order so children are not always appended at the end.
+func benchmarkPaths() []string {
+	ret := []string{}
+
+	for i := 0; i < 40; i++ {
+		for j := 0; j < 10; j++ {
+			for k := 0; k < 5; k++ {
+				ret = append(ret, fmt.Sprintf("fieldset%02d.object%02d.field%02d", i, j, k))
+			}
+
+			ret = append(ret, fmt.Sprintf("fieldset%02d.field%02d", i, j))
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	rng.Shuffle(len(ret), func(i, j int) {
+		ret[i], ret[j] = ret[j], ret[i]
+	})
+
+	return ret
+}
+
+// benchmarkDefs returns the benchmark paths as a tree of objects and fields for testRoot.
+func benchmarkDefs() map[string]*Definition {
+	ret := map[string]*Definition{}
+	for _, p := range benchmarkPaths() {
+		ret[p] = nil
+	}
+
+	return ret
+}
+
+func BenchmarkBranch(b *testing.B) {
+	paths := benchmarkPaths()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r := NewRoot()
+
+		for _, p := range paths {
+			if _, err := r.Branch(p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkChildNodes(b *testing.B) {
+	r := testRoot(b, benchmarkDefs())
+
+	var visit func(w Walkable) int
+	visit = func(w Walkable) int {
+		count := 0
+		for _, child := range w.ChildNodes() {
+			count += 1 + visit(child)
+		}
+
+		return count
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if visit(r) != len(r.Index) {
+			b.Fatal("did not visit every node")
+		}
+	}
+}
+
+// BenchmarkListChildren walks the same tree as BenchmarkChildNodes with the deprecated
+// channel based iteration, for comparison.
+func BenchmarkListChildren(b *testing.B) {
+	r := testRoot(b, benchmarkDefs())
+
+	var visit func(n *Node) int
+	visit = func(n *Node) int {
+		count := 1
+		for child := range n.ListChildren() {
+			count += visit(child)
+		}
+
+		return count
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		count := 0
+		for node := range r.ListChildren() {
+			count += visit(node)
+		}
+
+		if count != len(r.Index) {
+			b.Fatal("did not visit every node")
+		}
+	}
+}
+
+func TestChildNodesSorted(t *testing.T) {
+	tests := []struct {
+		name   string
+		add    []string
+		remove []string
+		want   []string
+	}{
+		{
+			name: "inserted in order",
+			add:  []string{"a", "b", "c"},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "inserted out of order",
+			add:  []string{"c", "a", "d", "b"},
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:   "removed from the middle",
+			add:    []string{"c", "a", "b"},
+			remove: []string{"b"},
+			want:   []string{"a", "c"},
+		},
+		{
+			name:   "removed from both ends",
+			add:    []string{"a", "b", "c", "d"},
+			remove: []string{"a", "d"},
+			want:   []string{"b", "c"},
+		},
+		{
+			name:   "removed everything",
+			add:    []string{"a"},
+			remove: []string{"a"},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRoot()
+
+			parent, err := r.Branch("parent")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range tt.add {
+				if _, err := r.Branch(name); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := parent.Child(name); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, name := range tt.remove {
+				if !r.Remove(name) || !parent.Remove(name) {
+					t.Fatalf("could not remove %s", name)
+				}
+			}
+
+			if got := names(parent.ChildNodes()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Node.ChildNodes() = %v, want %v", got, tt.want)
+			}
+
+			// the root also holds "parent"
+			want := append([]string{}, tt.want...)
+			want = insertName(want, "parent")
+
+			if got := names(r.ChildNodes()); !reflect.DeepEqual(got, want) {
+				t.Errorf("Root.ChildNodes() = %v, want %v", got, want)
+			}
+
+			listed := []string{}
+			for node := range parent.ListChildren() {
+				listed = append(listed, node.Name)
+			}
+
+			if !reflect.DeepEqual(listed, tt.want) {
+				t.Errorf("Node.ListChildren() = %v, want %v", listed, tt.want)
+			}
+		})
+	}
+}
+
+// names returns the names of a list of Nodes.
+func names(nodes []*Node) []string {
+	ret := []string{}
+	for _, n := range nodes {
+		ret = append(ret, n.Name)
+	}
+
+	return ret
+}
+
+// insertName inserts a name into a sorted list of names.
+func insertName(list []string, name string) []string {
+	for idx, x := range list {
+		if x > name {
+			return append(list[:idx], append([]string{name}, list[idx:]...)...)
+		}
+	}
+
+	return append(list, name)
+}
@@ -0,0 +1,224 @@
+// Package pipeline runs ecsgen from Go code, such as a go:generate tool, instead of the CLI.
+// A Pipeline loads the schema from any number of sources, applies the filters, and executes
+// generators that are configured with their typed options:
+//
+//	p := &pipeline.Pipeline{
+//		Sources: []config.Source{pipeline.FromFile("ecs_flat.yml", config.SourceFormatFlat)},
+//		Filters: config.Filters{IncludeFieldsets: []string{"event", "host"}},
+//		Generators: []generator.Generator{
+//			gostruct.NewWithOptions(gostruct.Options{PackageName: "ecs", OutputDir: "ecs"}),
+//		},
+//	}
+//
+//	err := p.Run(context.Background())
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"testing/fstest"
+
+	"github.com/gen0cide/ecsgen"
+	"github.com/gen0cide/ecsgen/config"
+	"github.com/gen0cide/ecsgen/generator"
+	"github.com/gen0cide/ecsgen/generator/external"
+	"github.com/gen0cide/ecsgen/loader"
+)
+
+// Pipeline holds everything needed to load a schema and generate code from it. The fields
+// have the same meaning as the CLI flags of the same names.
+type Pipeline struct {
+	// Sources are loaded in order, with later sources merged on top of earlier ones. At
+	// least one is required.
+	Sources []config.Source
+
+	AllowOverrides    bool
+	CollisionStrategy string
+	CollisionSuffix   string
+	Filters           config.Filters
+	TypeOverrides     map[string]string
+	Initialisms       []string
+
+	// Generators are executed concurrently. Use generator.WithFilters to run a generator
+	// against only part of the schema.
+	Generators []generator.Generator
+
+	// FailFast executes the generators one at a time, in order, stopping at the first failure.
+	FailFast bool
+
+	// Output receives the generated files. If it is nil, the files are written
+	// relative to the working directory.
+	Output generator.Output
+}
+
+// FromFile creates a source that reads a schema file, or directory for the directory
+// based formats, from disk.
+func FromFile(path string, format string) config.Source {
+	return config.Source{
+		Path:   path,
+		Format: format,
+	}
+}
+
+// FromFS creates a source that reads a schema file, or directory for the directory
+// based formats, from a file system such as an embed.FS.
+func FromFS(fsys fs.FS, path string, format string) config.Source {
+	return config.Source{
+		Path:   path,
+		Format: format,
+		FS:     fsys,
+	}
+}
+
+// FromReader creates a source that reads a single schema file from r. The name identifies
+// the source in errors, and must be a valid fs.FS path (i.e. "ecs_flat.yml").
+func FromReader(name string, format string, r io.Reader) (config.Source, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return config.Source{}, fmt.Errorf("invalid source name %q", name)
+	}
+
+	if format == config.SourceFormatSchemas {
+		return config.Source{}, fmt.Errorf("the %s source format is a directory, and cannot be read from a reader", format)
+	}
+
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return config.Source{}, fmt.Errorf("error reading %s: %v", name, err)
+	}
+
+	fsys := fstest.MapFS{
+		name: &fstest.MapFile{Data: contents},
+	}
+
+	return FromFS(fsys, name, format), nil
+}
+
+// Config creates the config.Config the Pipeline is run with.
+func (p *Pipeline) Config() (*config.Config, error) {
+	return config.New(config.Options{
+		Sources:           p.Sources,
+		AllowOverrides:    p.AllowOverrides,
+		CollisionStrategy: p.CollisionStrategy,
+		CollisionSuffix:   p.CollisionSuffix,
+		Filters:           p.Filters,
+		TypeOverrides:     p.TypeOverrides,
+		Initialisms:       p.Initialisms,
+		Generators:        p.Generators,
+		FailFast:          p.FailFast,
+	})
+}
+
+// Load loads, filters and validates the schema, returning the resulting tree. If validation
+// finds any errors, the returned error is the loader.Diagnostics that were found.
+func (p *Pipeline) Load(ctx context.Context) (*ecsgen.Root, error) {
+	c, err := p.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.load(ctx, c)
+}
+
+// Run loads the schema and executes every generator against it. If any generators fail,
+// the returned error is the generator.Errors of the ones that failed.
+func (p *Pipeline) Run(ctx context.Context) error {
+	c, err := p.Config()
+	if err != nil {
+		return err
+	}
+
+	root, err := p.load(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	out := p.Output
+	if out == nil {
+		out = generator.NewDirOutput(config.DefaultOutput)
+	}
+
+	return Execute(ctx, c, root, out)
+}
+
+// load creates the tree for a validated config.
+func (p *Pipeline) load(ctx context.Context, c *config.Config) (*ecsgen.Root, error) {
+	schemaLoader, err := Load(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// warnings do not prevent generation, so only errors are returned
+	diags := schemaLoader.Validate()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return schemaLoader.Root(), nil
+}
+
+// Load loads and filters the schema of a Config, such as one created from CLI flags. The
+// returned Loader holds the tree and the filter report, and its Validate method reports
+// the problems found in the schema, which Load does not check.
+func Load(ctx context.Context, c *config.Config) (*loader.Loader, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	schemaLoader, err := loader.NewLoader(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = schemaLoader.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return schemaLoader, nil
+}
+
+// Execute runs every generator of a Config against the tree, writing the generated files to
+// out. The tree is frozen first, and generators with their own filters are given a view of it.
+// If any generators fail, the returned error is the generator.Errors of the ones that failed.
+func Execute(ctx context.Context, c *config.Config, root *ecsgen.Root, out generator.Output) error {
+	generators, err := c.Generators()
+	if err != nil {
+		return err
+	}
+
+	// the generators run concurrently, so the tree cannot change while they use it
+	root.Freeze()
+
+	tasks := []generator.Task{}
+
+	for _, g := range generators {
+		// generators with their own filters get a view of the tree
+		view, err := c.GeneratorRoot(g, root)
+		if err != nil {
+			return err
+		}
+
+		tasks = append(tasks, generator.Task{Generator: g, Root: view})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return generator.Execute(tasks, out, c.FailFast)
+}
+
+// ExternalPlugin creates the external plugin with the given ID, which is looked for on the
+// PATH (see package external). A Pipeline never discovers external plugins on its own, so
+// they have to be created with ExternalPlugin and added to the Generators.
+func ExternalPlugin(id string, opts external.Options) (generator.Generator, error) {
+	path, err := external.Lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return external.NewWithOptions(id, path, opts), nil
+}
@@ -0,0 +1,281 @@
+package ecsgen
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidQuery is returned (wrapped) when a query expression cannot be parsed.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Query is a compiled expression that selects Nodes from the schema tree. A query is made
+// of a path pattern followed by any number of predicates in square brackets:
+//
+//	process.**[type=keyword]
+//	*.ip[level=core]
+//	**[has(allowed_values)][!array]
+//
+// The path pattern is matched against each element of a Node's Path. "*" matches any
+// single element (and can be combined with other characters, i.e. "geo*"), while "**"
+// matches zero or more elements, so "process.**" matches "process" and every Node
+// beneath it. An empty path pattern is the same as "**".
+//
+// The supported predicates are:
+//
+//	[key=value]  the Definition value with the config key (i.e. type, level, format) equals value
+//	[key!=value] the Definition value with the config key does not equal value
+//	[has(key)]   the Definition has a non-empty value for the config key (i.e. allowed_values)
+//	[array]      the Node is an array (see Node.IsArray)
+//
+// Any predicate can be negated by prefixing it with "!". Nodes without a Definition are
+// implied objects, and only match [type=object]. A Node must match every predicate.
+type Query struct {
+	expr       string
+	segments   []string
+	predicates []predicate
+}
+
+// predicate is a single condition within a Query.
+type predicate struct {
+	negate bool
+	kind   string
+	key    string
+	value  string
+}
+
+const (
+	predicateEquals = "equals"
+	predicateHas    = "has"
+	predicateArray  = "array"
+)
+
+// ParseQuery compiles a query expression. The returned error wraps ErrInvalidQuery.
+func ParseQuery(expr string) (*Query, error) {
+	q := &Query{expr: expr}
+
+	pattern := expr
+	rest := ""
+	if idx := strings.Index(expr, "["); idx >= 0 {
+		pattern, rest = expr[:idx], expr[idx:]
+	}
+
+	// an empty pattern selects everything
+	if pattern == "" {
+		pattern = "**"
+	}
+
+	for _, seg := range strings.Split(pattern, ".") {
+		if seg == "" {
+			return nil, fmt.Errorf("%w %q: empty path element", ErrInvalidQuery, expr)
+		}
+
+		// make sure the element is a valid pattern up front, rather than at match time
+		if _, err := path.Match(seg, ""); err != nil {
+			return nil, fmt.Errorf("%w %q: bad path element %q", ErrInvalidQuery, expr, seg)
+		}
+
+		q.segments = append(q.segments, seg)
+	}
+
+	for rest != "" {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("%w %q: unexpected %q after predicate", ErrInvalidQuery, expr, rest)
+		}
+
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("%w %q: unterminated predicate", ErrInvalidQuery, expr)
+		}
+
+		p, err := parsePredicate(rest[1:end])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidQuery, expr, err)
+		}
+
+		q.predicates = append(q.predicates, p)
+		rest = rest[end+1:]
+	}
+
+	return q, nil
+}
+
+// parsePredicate parses the contents of a single [...] predicate.
+func parsePredicate(body string) (predicate, error) {
+	p := predicate{}
+
+	body = strings.TrimSpace(body)
+	if strings.HasPrefix(body, "!") && !strings.HasPrefix(body, "!=") {
+		p.negate = true
+		body = strings.TrimSpace(body[1:])
+	}
+
+	switch {
+	case body == predicateArray:
+		p.kind = predicateArray
+	case strings.HasPrefix(body, predicateHas+"(") && strings.HasSuffix(body, ")"):
+		p.kind = predicateHas
+		p.key = strings.TrimSpace(body[len(predicateHas)+1 : len(body)-1])
+	case strings.Contains(body, "!="):
+		parts := strings.SplitN(body, "!=", 2)
+		p.kind = predicateEquals
+		p.negate = !p.negate
+		p.key, p.value = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	case strings.Contains(body, "="):
+		parts := strings.SplitN(body, "=", 2)
+		p.kind = predicateEquals
+		p.key, p.value = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	default:
+		return p, fmt.Errorf("unknown predicate [%s]", body)
+	}
+
+	if p.kind != predicateArray {
+		if _, found := definitionFields[p.key]; !found {
+			return p, fmt.Errorf("unknown definition key %q", p.key)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the expression the Query was parsed from.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Match returns true if the Node matches the Query.
+func (q *Query) Match(n *Node) bool {
+	return q.MatchDefinition(n.Path, n.Definition)
+}
+
+// MatchDefinition returns true if a Definition with the given ECS key matches the Query. This
+// allows definitions to be matched before they are added to the schema tree. A nil Definition
+// is treated as an implied object.
+func (q *Query) MatchDefinition(id string, def *Definition) bool {
+	if !matchSegments(q.segments, strings.Split(id, ".")) {
+		return false
+	}
+
+	for _, p := range q.predicates {
+		if p.match(def) != !p.negate {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Select returns every Node beneath a starting point that matches the Query, in the
+// order a depth first walk visits them.
+func (q *Query) Select(root Walkable) []*Node {
+	ret := []*Node{}
+
+	// the walk callback never fails, so neither can the walk
+	_ = Walk(root, func(n *Node) error {
+		if q.Match(n) {
+			ret = append(ret, n)
+		}
+
+		return nil
+	})
+
+	return ret
+}
+
+// Select parses a query expression and returns every Node in the tree that matches it.
+// See Query for the syntax.
+func (r *Root) Select(expr string) ([]*Node, error) {
+	q, err := ParseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Select(r), nil
+}
+
+// matchSegments matches the elements of a path against a path pattern.
+func matchSegments(pattern []string, elms []string) bool {
+	if len(pattern) == 0 {
+		return len(elms) == 0
+	}
+
+	if pattern[0] == "**" {
+		// try consuming every possible number of elements, including none
+		for i := 0; i <= len(elms); i++ {
+			if matchSegments(pattern[1:], elms[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(elms) == 0 {
+		return false
+	}
+
+	// patterns are checked when the query is parsed, so an error can't happen here
+	if ok, _ := path.Match(pattern[0], elms[0]); !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], elms[1:])
+}
+
+// match evaluates the predicate against a Definition, ignoring negation.
+func (p predicate) match(def *Definition) bool {
+	switch p.kind {
+	case predicateArray:
+		return (&Node{Definition: def}).IsArray()
+	case predicateHas:
+		if def == nil {
+			return false
+		}
+
+		val := reflect.ValueOf(def).Elem().Field(definitionFields[p.key])
+		if val.Kind() == reflect.Slice {
+			return val.Len() > 0
+		}
+
+		return !val.IsZero()
+	}
+
+	// implied objects only have a type
+	if def == nil {
+		return p.key == "type" && p.value == "object"
+	}
+
+	val := reflect.ValueOf(def).Elem().Field(definitionFields[p.key])
+
+	// for lists, any element can match (i.e. [normalize=array])
+	if val.Kind() == reflect.Slice {
+		for i := 0; i < val.Len(); i++ {
+			if fmt.Sprint(val.Index(i).Interface()) == p.value {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return fmt.Sprint(val.Interface()) == p.value
+}
+
+// definitionFields maps the config key of each Definition field to its index.
+var definitionFields = func() map[string]int {
+	ret := map[string]int{}
+
+	t := reflect.TypeOf(Definition{})
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("config"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		ret[key] = i
+	}
+
+	return ret
+}()
@@ -0,0 +1,140 @@
+package ecsgen
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "empty path element", expr: "process..pid"},
+		{name: "trailing separator", expr: "process."},
+		{name: "bad pattern", expr: "process.[a"},
+		{name: "unterminated predicate", expr: "process.*[type=keyword"},
+		{name: "text after predicate", expr: "process.*[type=keyword]pid"},
+		{name: "unknown predicate", expr: "**[keyword]"},
+		{name: "unknown definition key", expr: "**[colour=blue]"},
+		{name: "unknown has key", expr: "**[has(colour)]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseQuery(tt.expr)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("ParseQuery(%q) error = %v, want ErrInvalidQuery", tt.expr, err)
+			}
+		})
+	}
+}
+
+func TestQueryMatchDefinition(t *testing.T) {
+	keyword := &Definition{Type: "keyword", Level: LevelCore}
+	array := &Definition{Type: "keyword", Level: LevelExtended, Normalize: []string{"array"}}
+	allowed := &Definition{Type: "keyword", AllowedValues: []*AllowedValue{{Name: "start"}}}
+
+	tests := []struct {
+		name string
+		expr string
+		id   string
+		def  *Definition
+		want bool
+	}{
+		{name: "exact path", expr: "process.pid", id: "process.pid", def: keyword, want: true},
+		{name: "exact path mismatch", expr: "process.pid", id: "process.name", def: keyword, want: false},
+		{name: "star matches one element", expr: "*.ip", id: "client.ip", def: keyword, want: true},
+		{name: "star does not match two elements", expr: "*.ip", id: "client.nat.ip", def: keyword, want: false},
+		{name: "star within element", expr: "geo*.city", id: "geoip.city", def: keyword, want: true},
+		{name: "double star matches no elements", expr: "process.**", id: "process", def: nil, want: true},
+		{name: "double star matches many elements", expr: "process.**", id: "process.parent.pid", def: keyword, want: true},
+		{name: "double star in the middle", expr: "client.**.ip", id: "client.nat.ip", def: keyword, want: true},
+		{name: "double star in the middle matches none", expr: "client.**.ip", id: "client.ip", def: keyword, want: true},
+		{name: "double star requires prefix", expr: "process.**", id: "parent.pid", def: keyword, want: false},
+		{name: "empty pattern matches everything", expr: "[type=keyword]", id: "a.b.c", def: keyword, want: true},
+		{name: "equals", expr: "**[type=keyword]", id: "a", def: keyword, want: true},
+		{name: "equals mismatch", expr: "**[type=long]", id: "a", def: keyword, want: false},
+		{name: "not equals", expr: "**[level!=core]", id: "a", def: array, want: true},
+		{name: "not equals mismatch", expr: "**[level!=core]", id: "a", def: keyword, want: false},
+		{name: "negated equals", expr: "**[!level=core]", id: "a", def: keyword, want: false},
+		{name: "equals any list element", expr: "**[normalize=array]", id: "a", def: array, want: true},
+		{name: "has", expr: "**[has(allowed_values)]", id: "a", def: allowed, want: true},
+		{name: "has empty", expr: "**[has(allowed_values)]", id: "a", def: keyword, want: false},
+		{name: "array", expr: "**[array]", id: "a", def: array, want: true},
+		{name: "not array", expr: "**[!array]", id: "a", def: array, want: false},
+		{name: "every predicate must match", expr: "**[type=keyword][level=core]", id: "a", def: array, want: false},
+		{name: "implied object is an object", expr: "**[type=object]", id: "a", def: nil, want: true},
+		{name: "implied object has no level", expr: "**[level=core]", id: "a", def: nil, want: false},
+		{name: "implied object has nothing", expr: "**[has(type)]", id: "a", def: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.expr, err)
+			}
+
+			if got := q.MatchDefinition(tt.id, tt.def); got != tt.want {
+				t.Errorf("ParseQuery(%q).MatchDefinition(%q) = %v, want %v", tt.expr, tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRootSelect(t *testing.T) {
+	r := NewRoot()
+
+	for id, def := range map[string]*Definition{
+		"process.pid":        {Type: "long", Level: LevelCore},
+		"process.name":       {Type: "keyword", Level: LevelExtended},
+		"process.parent.pid": {Type: "long", Level: LevelExtended},
+		"client.ip":          {Type: "ip", Level: LevelCore},
+	} {
+		node, err := r.Branch(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node.Definition = def
+	}
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{expr: "process.**[type=long]", want: []string{"process.parent.pid", "process.pid"}},
+		{expr: "**[level=core]", want: []string{"client.ip", "process.pid"}},
+		{expr: "*", want: []string{"client", "process"}},
+		{expr: "nothing.**", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			nodes, err := r.Select(tt.expr)
+			if err != nil {
+				t.Fatalf("Select(%q) error = %v", tt.expr, err)
+			}
+
+			if got := paths(nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := r.Select("process..pid"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Select with an invalid query error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+// paths returns the paths of a list of Nodes.
+func paths(nodes []*Node) []string {
+	ret := []string{}
+	for _, n := range nodes {
+		ret = append(ret, n.Path)
+	}
+
+	return ret
+}
@@ -1,8 +1,6 @@
 package ecsgen
 
 import (
-	"errors"
-	"sort"
 	"strings"
 )
 
@@ -14,13 +12,30 @@ type Root struct {
 
 	// Index holds references to each node by absolute path
 	Index map[string]*Node
+
+	// Fieldsets holds the fieldset definitions that were loaded with the schema,
+	// keyed by fieldset name. This includes root fieldsets (such as "base") that
+	// do not map to an object Node. It is empty for sources that carry no
+	// fieldset metadata, such as "ecs_flat.yml".
+	Fieldsets map[string]*Fieldset
+
+	// Initialisms are kept capitalized in the Identifiers of the Nodes, in addition
+	// to the DefaultInitialisms. See Identifier.
+	Initialisms Initialisms
+
+	// sorted holds the same Nodes as TopLevel, sorted by Name.
+	sorted []*Node
+
+	// readOnly prevents Nodes from being added or removed. See View and Freeze.
+	readOnly bool
 }
 
 // NewRoot creates an empty Root.
 func NewRoot() *Root {
 	return &Root{
-		TopLevel: map[string]*Node{},
-		Index:    map[string]*Node{},
+		TopLevel:  map[string]*Node{},
+		Index:     map[string]*Node{},
+		Fieldsets: map[string]*Fieldset{},
 	}
 }
 
@@ -28,16 +43,27 @@ func NewRoot() *Root {
 // previously unknown Node's within the graph to traverse to the specified path.
 // For example, if you passed "client.as.organization.name", it would perform the
 // following lookups: Node("client").Child("as").Child("organization").Child("name").
-func (r *Root) Branch(branchpath string) *Node {
+// A *SchemaError is returned if the path is empty or contains an empty element, or if
+// the Node does not exist and the tree is read-only.
+func (r *Root) Branch(branchpath string) (*Node, error) {
 	if branchpath == "" {
-		panic(errors.New("cannot have an empty branch path"))
+		return nil, &SchemaError{Cause: ErrEmptyPath}
+	}
+
+	// read-only trees can only resolve the Nodes they already have
+	if r.readOnly {
+		if node, found := r.Index[branchpath]; found {
+			return node, nil
+		}
+
+		return nil, &SchemaError{Path: branchpath, Cause: ErrReadOnly}
 	}
 
 	// short circuit if the provided path is a top level object
 	if !strings.Contains(branchpath, ".") {
 		if node, found := r.TopLevel[branchpath]; found {
 			// top level object already exists, return it
-			return node
+			return node, nil
 		}
 
 		// create the new top level object
@@ -53,51 +79,130 @@ func (r *Root) Branch(branchpath string) *Node {
 
 		// add it to the top level tree
 		r.TopLevel[branchpath] = node
+		r.sorted = insertSorted(r.sorted, node)
 
-		return node
+		return node, nil
 	}
 
-	var node *Node
 	// we need to walk the path. lets start by splitting the path
 	// into their individual elements.
 	// i.e. "client.as.organization.name" => ["client", "as", "organization", "name"]
 	pathelms := strings.Split(branchpath, ".")
 
+	// make sure there are no empty elements before we create anything,
+	// i.e. "client..name" or "client.name."
+	for _, elm := range pathelms {
+		if elm == "" {
+			return nil, &SchemaError{Path: branchpath, Cause: ErrInvalidName}
+		}
+	}
+
 	// get the root
-	node = r.Branch(pathelms[0])
+	node, err := r.Branch(pathelms[0])
+	if err != nil {
+		return nil, err
+	}
 
 	// enumerate all children
 	for i := 1; i < len(pathelms); i++ {
-		node = node.Child(pathelms[i])
+		node, err = node.Child(pathelms[i])
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return node
+	return node, nil
 }
 
-// ListChildren implements the Walkable interface.
-func (r *Root) ListChildren() <-chan *Node {
-	// create the return channel, close it once we're done
-	ret := make(chan *Node, len(r.TopLevel))
-	defer close(ret)
+// Lookup is used to resolve an existing Node within the tree by its absolute path. Unlike
+// Branch, it never creates Nodes, so it is safe to use for checking if a path exists.
+func (r *Root) Lookup(nodepath string) (*Node, bool) {
+	node, found := r.Index[nodepath]
+	return node, found
+}
 
-	// short circuit if we've got no elements
-	if len(r.TopLevel) == 0 {
-		return ret
+// Remove removes the Node at the specified path, along with all of its children, from
+// the tree. Both the Index and the parent's Children (or TopLevel) are updated. It returns
+// false if no Node exists at the path, or if the tree is read-only.
+func (r *Root) Remove(nodepath string) bool {
+	node, found := r.Lookup(nodepath)
+	if !found || r.readOnly {
+		return false
 	}
 
-	// get a list of child names from the map
-	// and sort them
-	keys := []string{}
-	for k := range r.TopLevel {
-		keys = append(keys, k)
+	if node.Parent != nil {
+		return node.Parent.Remove(node.Name)
 	}
 
-	sort.Strings(keys)
+	delete(r.TopLevel, node.Name)
+	r.sorted = removeSorted(r.sorted, node)
+	r.unindex(node)
 
-	// populate the channel in a predictable order
-	for _, k := range keys {
-		ret <- r.TopLevel[k]
+	return true
+}
+
+// Prune removes every object Node that has neither a Definition nor any children, such
+// as implied objects whose fields were all removed. Removing a Node can leave its parent
+// empty, so pruning continues up the tree. It returns the number of Nodes removed, which
+// is always 0 for a read-only tree.
+func (r *Root) Prune() int {
+	count := 0
+
+	if r.readOnly {
+		return count
 	}
 
-	return ret
+	for name, node := range r.TopLevel {
+		count += node.Prune()
+
+		if node.isPrunable() {
+			delete(r.TopLevel, name)
+			r.sorted = removeSorted(r.sorted, node)
+			r.unindex(node)
+			count++
+		}
+	}
+
+	return count
+}
+
+// unindex removes a Node and all of its children from the Index.
+func (r *Root) unindex(n *Node) {
+	delete(r.Index, n.Path)
+
+	for _, child := range n.Children {
+		r.unindex(child)
+	}
+}
+
+// Identifier creates an Identifier from a provided string that keeps the Root's Initialisms capitalized.
+func (r *Root) Identifier(s string) Identifier {
+	return r.Initialisms.Identifier(s)
+}
+
+// IsReadOnly returns true if Nodes cannot be added to or removed from the tree.
+func (r *Root) IsReadOnly() bool {
+	return r.readOnly
+}
+
+// Freeze makes the tree read-only, so it can be safely shared by consumers running
+// concurrently, such as output plugins. It cannot be undone. Freezing only prevents
+// Nodes from being added or removed; the Definitions and Fieldsets must not be modified.
+func (r *Root) Freeze() {
+	r.readOnly = true
+}
+
+// ChildNodes implements the Walkable interface. The returned slice is shared with the Root
+// and must not be modified. It is only valid until a top level Node is added or removed,
+// as that updates the slice in place.
+func (r *Root) ChildNodes() []*Node {
+	return r.sorted
+}
+
+// ListChildren returns a channel that yields the top level Nodes, sorted by Name.
+//
+// Deprecated: ListChildren allocates a channel on every call and is no longer part of the
+// Walkable interface. Use ChildNodes instead.
+func (r *Root) ListChildren() <-chan *Node {
+	return listNodes(r.sorted)
 }
@@ -0,0 +1,31 @@
+package ecsgen
+
+import (
+	"sort"
+	"testing"
+)
+
+// testRoot returns a tree with a Node for every path in defs, with the given Definition. Paths
+// with a nil Definition are only branched, so the tree can hold objects and fields alike.
+func testRoot(tb testing.TB, defs map[string]*Definition) *Root {
+	r := NewRoot()
+
+	// branch in a stable order, so failures are repeatable
+	paths := []string{}
+	for path := range defs {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		node, err := r.Branch(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		node.Definition = defs[path]
+	}
+
+	return r
+}
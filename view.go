@@ -0,0 +1,59 @@
+package ecsgen
+
+// View returns a read-only copy of the tree that only contains the Nodes selected by keep,
+// along with the objects above them. keep is called for every Node with a Definition, and
+// implied objects are only kept if any of their descendants are. This allows a single loaded
+// tree to be handed to several consumers that each need a different part of the schema.
+//
+// The returned Root cannot have Nodes added or removed. The Definitions and Fieldsets are
+// shared with the original tree, and must not be modified.
+func (r *Root) View(keep func(n *Node) bool) *Root {
+	view := NewRoot()
+	view.Initialisms = r.Initialisms
+
+	for name, fieldset := range r.Fieldsets {
+		view.Fieldsets[name] = fieldset
+	}
+
+	// the children are already sorted, so the copies can be appended in order
+	for _, node := range r.sorted {
+		if copied := copyNode(view, nil, node, keep); copied != nil {
+			view.TopLevel[copied.Name] = copied
+			view.sorted = append(view.sorted, copied)
+		}
+	}
+
+	view.readOnly = true
+
+	return view
+}
+
+// copyNode copies a Node and the descendants selected by keep into view. It returns
+// nil if neither the Node nor any of its descendants were selected.
+func copyNode(view *Root, parent *Node, n *Node, keep func(n *Node) bool) *Node {
+	copied := &Node{
+		Name:       n.Name,
+		Path:       n.Path,
+		Parent:     parent,
+		Root:       view,
+		Children:   map[string]*Node{},
+		Definition: n.Definition,
+		Fieldset:   n.Fieldset,
+	}
+
+	for _, child := range n.sorted {
+		if c := copyNode(view, copied, child, keep); c != nil {
+			copied.Children[c.Name] = c
+			copied.sorted = append(copied.sorted, c)
+		}
+	}
+
+	// objects above a selected Node are always kept
+	if len(copied.Children) == 0 && (n.Definition == nil || !keep(n)) {
+		return nil
+	}
+
+	view.Index[copied.Path] = copied
+
+	return copied
+}
@@ -0,0 +1,177 @@
+package ecsgen
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// viewDefs is a tree of fields under implied objects and under a nested object with a
+// Definition of its own.
+var viewDefs = map[string]*Definition{
+	"process.pid":                   {Type: "long"},
+	"process.parent.pid":            {Type: "long"},
+	"threat.enrichments":            {Type: "nested"},
+	"threat.enrichments.indicator":  {Type: "keyword"},
+	"threat.framework":              {Type: "keyword"},
+	"client.nat.ip":                 {Type: "ip"},
+	"client.nat.port":               {Type: "long"},
+	"client.address":                {Type: "keyword"},
+	"threat.enrichments.matched.id": {Type: "keyword"},
+}
+
+func TestRootView(t *testing.T) {
+	tests := []struct {
+		name string
+		keep func(n *Node) bool
+		want []string
+	}{
+		{
+			name: "everything",
+			keep: func(*Node) bool { return true },
+			want: []string{
+				"client", "client.address", "client.nat", "client.nat.ip", "client.nat.port",
+				"process", "process.parent", "process.parent.pid", "process.pid",
+				"threat", "threat.enrichments", "threat.enrichments.indicator", "threat.enrichments.matched",
+				"threat.enrichments.matched.id", "threat.framework",
+			},
+		},
+		{
+			name: "nothing",
+			keep: func(*Node) bool { return false },
+			want: []string{},
+		},
+		{
+			name: "ancestors of kept fields are kept",
+			keep: func(n *Node) bool { return n.Path == "client.nat.ip" },
+			want: []string{"client", "client.nat", "client.nat.ip"},
+		},
+		{
+			name: "objects with a definition are kept on their own",
+			keep: func(n *Node) bool { return n.Path == "threat.enrichments" },
+			want: []string{"threat", "threat.enrichments"},
+		},
+		{
+			name: "implied objects without kept descendants are dropped",
+			keep: func(n *Node) bool { return strings.HasSuffix(n.Path, ".pid") },
+			want: []string{"process", "process.parent", "process.parent.pid", "process.pid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRoot(t, viewDefs)
+			r.Fieldsets["process"] = &Fieldset{Name: "process"}
+
+			view := r.View(tt.keep)
+
+			got := []string{}
+			_ = Walk(view, func(n *Node) error {
+				got = append(got, n.Path)
+				return nil
+			})
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("View() = %v, want %v", got, tt.want)
+			}
+
+			// the index holds exactly the Nodes in the view
+			indexed := []string{}
+			for p, n := range view.Index {
+				indexed = append(indexed, p)
+
+				if n.Root != view {
+					t.Errorf("Node %s does not belong to the view", p)
+				}
+			}
+
+			sort.Strings(indexed)
+
+			if !reflect.DeepEqual(indexed, tt.want) {
+				t.Errorf("View().Index = %v, want %v", indexed, tt.want)
+			}
+
+			if view.Fieldsets["process"] != r.Fieldsets["process"] {
+				t.Error("View() did not keep the fieldsets")
+			}
+
+			// the original tree is not changed
+			if len(r.Index) != 15 || r.IsReadOnly() {
+				t.Errorf("View() changed the original tree")
+			}
+		})
+	}
+}
+
+func TestRootViewReadOnly(t *testing.T) {
+	view := testRoot(t, viewDefs).View(func(*Node) bool { return true })
+
+	if !view.IsReadOnly() {
+		t.Fatal("View() is not read-only")
+	}
+
+	// existing Nodes can still be resolved
+	if node, err := view.Branch("client.nat.ip"); err != nil || node.Path != "client.nat.ip" {
+		t.Errorf("Branch() of an existing Node = %v, %v", node, err)
+	}
+
+	client, _ := view.Lookup("client")
+	if child, err := client.Child("nat"); err != nil || child.Path != "client.nat" {
+		t.Errorf("Child() of an existing Node = %v, %v", child, err)
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{
+			name: "branch a new top level node",
+			fn: func() error {
+				_, err := view.Branch("host")
+				return err
+			},
+		},
+		{
+			name: "branch a new nested node",
+			fn: func() error {
+				_, err := view.Branch("client.nat.new")
+				return err
+			},
+		},
+		{
+			name: "add a child",
+			fn: func() error {
+				_, err := client.Child("new")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fn()
+			if !errors.Is(err, ErrReadOnly) {
+				t.Errorf("error = %v, want ErrReadOnly", err)
+			}
+
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Errorf("error = %v, want a *SchemaError", err)
+			}
+		})
+	}
+
+	if view.Remove("client.nat") || client.Remove("nat") {
+		t.Error("Remove() changed a read-only tree")
+	}
+
+	if view.Prune() != 0 {
+		t.Error("Prune() changed a read-only tree")
+	}
+
+	if _, found := view.Lookup("client.nat.ip"); !found {
+		t.Error("read-only tree lost a Node")
+	}
+}
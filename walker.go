@@ -1,48 +1,166 @@
 package ecsgen
 
 import (
+	"context"
 	"errors"
 )
 
 // Walkable represents types that can be walked within ecsgen. This allows walking
 // from arbitrary points within the graph, as well as from the root.
+//
+// ChildNodes replaced the channel based ListChildren method. Types that implement Walkable
+// outside of ecsgen must implement ChildNodes instead. Node and Root still have a deprecated
+// ListChildren method for callers that use it directly.
 type Walkable interface {
-	ListChildren() <-chan *Node
+	// ChildNodes returns the direct children, sorted by Name. The
+	// returned slice must not be modified.
+	ChildNodes() []*Node
 }
 
 // ErrSkipChildren is a used as a return value from WalkFuncs to indicate that the
 // callback should not be called for any children of the examined Node.
 var ErrSkipChildren = errors.New("node walker: skip remaining children")
 
+// ErrStopWalk is used as a return value from WalkFuncs to indicate that the walk should
+// stop immediately. The walk itself will return nil.
+var ErrStopWalk = errors.New("node walker: stop walking")
+
 // WalkFunc is the type of the function called for each child of a node.
 type WalkFunc func(n *Node) error
 
-// Walk is a simple depth first walker for traversing the Schema from a starting Node.
-func Walk(root Walkable, fn WalkFunc) error {
-	// enumerate all children of the root
-	for elm := range root.ListChildren() {
+// VisitFunc is the type of the function called by a Walker for each Node. The depth
+// is relative to where the walk started, with the direct children of the starting
+// point at a depth of 0. For a walk from the Root, this means top level Nodes are at 0.
+type VisitFunc func(n *Node, depth int) error
 
-		// call the walk func on the element
-		err := fn(elm)
+// Walker is a configurable walker for traversing the Schema. The zero value performs a
+// depth first walk that visits nothing, so at least one of Enter or Leave should be set.
+type Walker struct {
+	// Enter is called for each Node before any of its children are visited (pre-order).
+	// Returning ErrSkipChildren prevents the Node's children from being visited.
+	Enter VisitFunc
 
-		// if the returned error is an ErrSkipChildren, simply stop walking
-		// this branch and continue
-		if err == ErrSkipChildren {
-			continue
-		}
+	// Leave is called for each Node after all of its children have been visited
+	// (post-order). It is still called if Enter returned ErrSkipChildren, which makes
+	// it suitable for closing anything Enter opened. Leave is not supported when
+	// walking breadth first.
+	Leave VisitFunc
 
-		// something else happened, stop immediately and return the error
-		if err != nil {
+	// BreadthFirst visits all the Nodes at a given depth before any Nodes that are
+	// deeper, rather than walking each branch to the bottom first.
+	BreadthFirst bool
+}
+
+// Walk traverses the Schema from a starting point, calling the Walker's callbacks for
+// every Node. The callbacks must not add or remove Nodes, as the walk iterates over the
+// children in place. The walk stops with ctx.Err() if the context is cancelled, with nil if
+// a callback returns ErrStopWalk, and with the callback's error for any other error.
+func (w *Walker) Walk(ctx context.Context, root Walkable) error {
+	var err error
+
+	if w.BreadthFirst {
+		err = w.walkBreadthFirst(ctx, root)
+	} else {
+		err = w.walkDepthFirst(ctx, root, 0)
+	}
+
+	if err == ErrStopWalk {
+		return nil
+	}
+
+	return err
+}
+
+// walkDepthFirst recursively walks the children of a Walkable.
+func (w *Walker) walkDepthFirst(ctx context.Context, root Walkable, depth int) error {
+	// enumerate all children of the root
+	for _, elm := range root.ChildNodes() {
+		// bail out if the caller is no longer interested
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 
-		// recursively call Walk on the element, bubbling up any errors
+		skip := false
+
+		// call the enter func on the element. if the returned error is an
+		// ErrSkipChildren, simply stop walking this branch. something else
+		// happened, stop immediately and return the error
+		if w.Enter != nil {
+			err := w.Enter(elm, depth)
+			if err == ErrSkipChildren {
+				skip = true
+			} else if err != nil {
+				return err
+			}
+		}
+
+		// recursively walk the element, bubbling up any errors
 		// that arise in the recursive call
-		err = Walk(elm, fn)
-		if err != nil {
-			return err
+		if !skip {
+			err := w.walkDepthFirst(ctx, elm, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+
+		// now that all children are done, call the leave func
+		if w.Leave != nil {
+			err := w.Leave(elm, depth)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
+
+// walkBreadthFirst walks the children of a Walkable one depth at a time.
+func (w *Walker) walkBreadthFirst(ctx context.Context, root Walkable) error {
+	if w.Leave != nil {
+		return errors.New("node walker: leave callbacks are not supported when walking breadth first")
+	}
+
+	// the queue holds every Node at the current depth
+	queue := root.ChildNodes()
+
+	for depth := 0; len(queue) > 0; depth++ {
+		next := []*Node{}
+
+		for _, elm := range queue {
+			// bail out if the caller is no longer interested
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if w.Enter != nil {
+				err := w.Enter(elm, depth)
+				if err == ErrSkipChildren {
+					continue
+				}
+
+				if err != nil {
+					return err
+				}
+			}
+
+			next = append(next, elm.ChildNodes()...)
+		}
+
+		queue = next
+	}
+
+	return nil
+}
+
+// Walk is a simple depth first walker for traversing the Schema from a starting Node.
+// For cancellation, depth information, post-order callbacks or breadth first walks, use a Walker.
+func Walk(root Walkable, fn WalkFunc) error {
+	w := &Walker{
+		Enter: func(n *Node, _ int) error {
+			return fn(n)
+		},
+	}
+
+	return w.Walk(context.Background(), root)
+}
@@ -0,0 +1,160 @@
+package ecsgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// walkerDefs is a small tree for walking:
+//
+//	a
+//	  a.b
+//	    a.b.c
+//	  a.d
+//	e
+var walkerDefs = map[string]*Definition{"a.b.c": nil, "a.d": nil, "e": nil}
+
+func TestWalker(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		walker  func(visited *[]string) *Walker
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "enter is depth first pre-order",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Enter: record(visited, "enter", nil)}
+			},
+			want: []string{"enter a 0", "enter a.b 1", "enter a.b.c 2", "enter a.d 1", "enter e 0"},
+		},
+		{
+			name: "leave is post-order",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Enter: record(visited, "enter", nil), Leave: record(visited, "leave", nil)}
+			},
+			want: []string{
+				"enter a 0", "enter a.b 1", "enter a.b.c 2", "leave a.b.c 2", "leave a.b 1",
+				"enter a.d 1", "leave a.d 1", "leave a 0", "enter e 0", "leave e 0",
+			},
+		},
+		{
+			name: "leave is called when children are skipped",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{
+					Enter: record(visited, "enter", map[string]error{"a.b": ErrSkipChildren}),
+					Leave: record(visited, "leave", nil),
+				}
+			},
+			want: []string{
+				"enter a 0", "enter a.b 1", "leave a.b 1", "enter a.d 1", "leave a.d 1",
+				"leave a 0", "enter e 0", "leave e 0",
+			},
+		},
+		{
+			name: "stop walk ends the walk without an error",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Enter: record(visited, "enter", map[string]error{"a.b": ErrStopWalk})}
+			},
+			want: []string{"enter a 0", "enter a.b 1"},
+		},
+		{
+			name: "stop walk from leave",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Leave: record(visited, "leave", map[string]error{"a.b": ErrStopWalk})}
+			},
+			want: []string{"leave a.b.c 2", "leave a.b 1"},
+		},
+		{
+			name: "other errors end the walk",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Enter: record(visited, "enter", map[string]error{"a.d": errFailed})}
+			},
+			want:    []string{"enter a 0", "enter a.b 1", "enter a.b.c 2", "enter a.d 1"},
+			wantErr: errFailed,
+		},
+		{
+			name: "breadth first visits each depth in order",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Enter: record(visited, "enter", nil), BreadthFirst: true}
+			},
+			want: []string{"enter a 0", "enter e 0", "enter a.b 1", "enter a.d 1", "enter a.b.c 2"},
+		},
+		{
+			name: "breadth first skips children",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Enter: record(visited, "enter", map[string]error{"a.b": ErrSkipChildren}), BreadthFirst: true}
+			},
+			want: []string{"enter a 0", "enter e 0", "enter a.b 1", "enter a.d 1"},
+		},
+		{
+			name: "breadth first stop walk",
+			walker: func(visited *[]string) *Walker {
+				return &Walker{Enter: record(visited, "enter", map[string]error{"e": ErrStopWalk}), BreadthFirst: true}
+			},
+			want: []string{"enter a 0", "enter e 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visited := []string{}
+
+			err := tt.walker(&visited).Walk(context.Background(), testRoot(t, walkerDefs))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Walk() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(visited, tt.want) {
+				t.Errorf("Walk() visited %v, want %v", visited, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkerBreadthFirstLeave(t *testing.T) {
+	w := &Walker{Leave: func(*Node, int) error { return nil }, BreadthFirst: true}
+
+	if err := w.Walk(context.Background(), testRoot(t, walkerDefs)); err == nil {
+		t.Error("Walk() with a leave callback and breadth first did not fail")
+	}
+}
+
+func TestWalkerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	visited := []string{}
+	w := &Walker{
+		Enter: func(n *Node, depth int) error {
+			visited = append(visited, n.Path)
+			if n.Path == "a.b" {
+				cancel()
+			}
+
+			return nil
+		},
+	}
+
+	err := w.Walk(ctx, testRoot(t, walkerDefs))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Walk() error = %v, want context.Canceled", err)
+	}
+
+	if want := []string{"a", "a.b"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("Walk() visited %v, want %v", visited, want)
+	}
+}
+
+// record returns a VisitFunc that records each Node it is called for, returning the error
+// in errs for the Node's path, if any.
+func record(visited *[]string, name string, errs map[string]error) VisitFunc {
+	return func(n *Node, depth int) error {
+		*visited = append(*visited, fmt.Sprintf("%s %s %d", name, n.Path, depth))
+		return errs[n.Path]
+	}
+}